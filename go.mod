@@ -1,3 +1,5 @@
 module github.com/andersjosef/wacky-gif
 
 go 1.22.2
+
+require golang.org/x/image v0.23.0
@@ -1,371 +1,322 @@
 package main
 
 import (
+	"bytes"
+	"errors"
+	"flag"
 	"fmt"
 	"image"
 	"image/color"
-	"image/color/palette"
-	"image/draw"
 	"image/gif"
 	"image/jpeg"
 	"image/png"
-	"math"
-	"math/rand"
+	"io"
 	"os"
 	"path/filepath"
-	"sync"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/andersjosef/wacky-gif/wackygif"
+	"golang.org/x/image/bmp"
+	"golang.org/x/image/tiff"
+	"golang.org/x/image/webp"
 )
 
-const DELAY = 10 // Delay in 100th of a second
+// Everything that can be set from the command line
+type options struct {
+	fileSRC    string
+	fileDST    string
+	paletteSRC string
+	delay      int // In 100th of a second
+	loop       int // Same meaning as gif.GIF.LoopCount
+	seed       int64
+	noShuffle  bool
+	echo       float64  // How much of the previous frame stays visible, 0 for none
+	force      bool     // Write the GIF even when the destination doesn't end in .gif
+	transforms []string // Names of the transforms to use in order, nil for the default set
+	frames     int      // Number of frames, 0 for one per transform
+	list       bool     // Print the available transforms instead of making a GIF
+	verbose    bool     // Report details like the detected image format on stderr
+}
 
 func main() {
-	fileSRC, fileDST, err := getArguments()
-	if err != nil {
-		fmt.Println(err)
+	opts, err := getArguments()
+	if errors.Is(err, flag.ErrHelp) {
 		return
 	}
-	// Open and decode the source image
-	img, err := loadImage(fileSRC)
 	if err != nil {
-		fmt.Printf("Error loading image: %v\n", err)
+		fmt.Fprintf(os.Stderr, "%v\n%s\nrun with -h to see all the options\n", err, usage)
 		return
 	}
-
-	width := img.Bounds().Dx()
-	height := img.Bounds().Dy()
-
-	// List of transformation functions
-	transformations := []func(image.Image, int, int) draw.Image{
-		func(img image.Image, width, height int) draw.Image {
-			return convertImageHorizontal(img, width, height, 1, 1, 1)
-		},
-		func(img image.Image, width, height int) draw.Image {
-			newImg := convertImageHorizontal(img, width, height, 0, 1, 1)
-			newImg = convertImageHorizontal(newImg, width, height, 1, 1, 0)
-			newImg = convertImageHorizontal(newImg, width, height, 1, 1, 1)
-			return newImg
-		},
-		convertImageVertical,
-		func(img image.Image, width, height int) draw.Image { return adjustBrightness(img, width, height, 4) },
-		func(img image.Image, width, height int) draw.Image { return waveImage(img, width, height, 20, 20) },
-		func(img image.Image, width, height int) draw.Image {
-			return convertImageHorizontal(img, width, height, 1, 0, 1)
-		},
-		func(img image.Image, width, height int) draw.Image {
-			return convertImageHorizontal(img, width, height, 0, 1, 1)
-		},
-		func(img image.Image, width, height int) draw.Image {
-			newImg := kaleidoscopeImage(img, width, height)
-			newImg = mergeImages(img, newImg)
-			return newImg
-		},
-		func(img image.Image, width, height int) draw.Image {
-			newImg := convertImageHorizontal(img, width, height, 1, 1, 1)
-			newImg = convertImageHorizontal(newImg, width, height, 1, 1, 1)
-			newImg = convertImageHorizontal(newImg, width, height, 1, 1, 1)
-			return newImg
-		},
-		func(img image.Image, width, height int) draw.Image {
-			newImg := waveImage(img, width, height, 100, 20)
-			newImg = mergeImages(img, newImg)
-			return newImg
-		},
-		kaleidoscopeImage,
-		strong,
-		sickTwist,
-		func(img image.Image, width, height int) draw.Image {
-
-			newImg := sickTwist(img, width, height)
-			newImg = convertImageHorizontal(newImg, width, height, 1, 1, 1)
-			newImg = convertImageHorizontal(newImg, width, height, 1, 1, 1)
-			return newImg
-		},
-		func(img image.Image, width, height int) draw.Image {
-
-			newImg := sickTwist(img, width, height)
-			newImg = convertImageHorizontal(newImg, width, height, 1, 1, 1)
-			return newImg
-		},
+	if opts.list {
+		listTransforms(os.Stdout)
+		return
 	}
-
-	// Shuffle the transformations
-	shuffle(transformations)
-
-	var images []*image.Paletted
-	var delays []int
-	var wg sync.WaitGroup
-	var mu sync.Mutex
-
-	// Create a goroutine for each transformation function
-	// then convert them to paletted for the gif format
-	for _, transform := range transformations {
-		wg.Add(1)
-		go func(transform func(image.Image, int, int) draw.Image) {
-			defer wg.Done()
-			transformedImg := transform(img, width, height)
-			palettedImage := convertToPaletted(transformedImg)
-
-			mu.Lock()
-			images = append(images, palettedImage)
-			delays = append(delays, DELAY)
-			mu.Unlock()
-		}(transform)
+	// Details only go to stderr when asked for
+	var logOut io.Writer = io.Discard
+	if opts.verbose {
+		logOut = os.Stderr
+	}
+	// Open and decode the source image
+	img, err := loadImage(opts.fileSRC, logOut)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading image: %v\n", err)
+		return
 	}
 
-	wg.Wait() // Wait for all the goroutines
-
-	// Create GIF
-	outputGif := &gif.GIF{
-		Image: images,
-		Delay: delays,
+	// Colors used in the GIF, optionally taken from a user supplied swatch
+	var pal color.Palette
+	if opts.paletteSRC != "" {
+		paletteImg, err := loadImage(opts.paletteSRC, logOut)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading palette image: %v\n", err)
+			return
+		}
+		pal = wackygif.PaletteFromImage(paletteImg)
+		if len(pal) == 0 {
+			fmt.Fprintln(os.Stderr, "Error loading palette image: image has no pixels")
+			return
+		}
 	}
 
-	// Make GIF file
-	outputFile, err := os.Create(fileDST)
+	outputGif, err := wackygif.GenerateGIF(img, wackygif.Options{
+		Delay:      opts.delay,
+		LoopCount:  opts.loop,
+		Seed:       opts.seed,
+		NoShuffle:  opts.noShuffle,
+		Echo:       opts.echo,
+		Palette:    pal,
+		Transforms: opts.transforms,
+		Frames:     opts.frames,
+	})
 	if err != nil {
-		fmt.Println("Error creating GIF file:", err)
+		fmt.Fprintln(os.Stderr, "Error creating GIF:", err)
 		return
 	}
-	defer outputFile.Close()
+
+	// Write to stdout when the destination is "-", otherwise make the GIF file
+	// along with any folders leading up to it
+	output := os.Stdout
+	if opts.fileDST != "-" {
+		if err := os.MkdirAll(filepath.Dir(opts.fileDST), 0o755); err != nil {
+			fmt.Fprintln(os.Stderr, "Error creating folder for GIF file:", err)
+			return
+		}
+		outputFile, err := os.Create(opts.fileDST)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error creating GIF file:", err)
+			return
+		}
+		defer outputFile.Close()
+		output = outputFile
+	}
 
 	// Write GIF to GIF file
-	err = gif.EncodeAll(outputFile, outputGif)
+	err = gif.EncodeAll(output, outputGif)
 	if err != nil {
-		fmt.Println("Error encoding GIF:", err)
+		fmt.Fprintln(os.Stderr, "Error encoding GIF:", err)
 	}
 }
 
-func loadImage(path string) (image.Image, error) {
-	file, err := os.Open(path)
+// Decodes the image at path, or from stdin when path is "-". Stdin has no extension
+// to go by so its format is always detected from the data, which works for every
+// format with a registered decoder (png, jpeg, gif, webp, bmp and tiff). Detected
+// formats are reported to log
+func loadImage(path string, log io.Writer) (image.Image, error) {
+	if path == "-" {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return nil, fmt.Errorf("reading stdin: %w", err)
+		}
+		img, format, err := image.Decode(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("decoding stdin: %w", err)
+		}
+		fmt.Fprintf(log, "stdin decoded as %s\n", format)
+		return img, nil
+	}
+
+	data, err := os.ReadFile(path)
 	if err != nil {
 		return nil, err
 	}
-	defer file.Close()
 
+	var img image.Image
 	ext := filepath.Ext(path)
-	switch ext {
-	case ".png":
-		return png.Decode(file)
-	case ".jpg", ".jpeg":
-		return jpeg.Decode(file)
-	default:
-		return nil, fmt.Errorf("unsupported file type: %s", ext)
+	if decode := decoderFor(ext); decode != nil {
+		img, err = decode(bytes.NewReader(data))
+	} else {
+		err = fmt.Errorf("unsupported file type: %s", ext)
+	}
+	if err == nil {
+		return img, nil
 	}
-}
-
-func convertToPaletted(img image.Image) *image.Paletted {
-	bounds := img.Bounds()
-	paletted := image.NewPaletted(bounds, palette.Plan9)
-	draw.FloydSteinberg.Draw(paletted, bounds, img, image.Point{})
-	return paletted
-}
 
-func shuffle(slice []func(image.Image, int, int) draw.Image) {
-	for i := len(slice) - 1; i > 0; i-- {
-		j := rand.Intn(i + 1)
-		slice[i], slice[j] = slice[j], slice[i]
+	// The extension was unknown or lied about the contents, let the magic bytes decide
+	img, format, sniffErr := image.Decode(bytes.NewReader(data))
+	if sniffErr != nil {
+		return nil, fmt.Errorf("decoding %s: %w, detecting the format: %w", path, err, sniffErr)
 	}
+	fmt.Fprintf(log, "%s decoded as %s\n", path, format)
+	return img, nil
 }
 
-// Handeling the arguments for sourcefile destination file and stepsize
-func getArguments() (fileSRC, fileDST string, err error) {
-	if len(os.Args) != 3 {
-		return "", "", fmt.Errorf("usage: ./program /source/path.jpeg /destination/path.gif")
+// Decoder for files with the extension ext, nil when it isn't one we know. The extension
+// is matched regardless of case so photo.JPG decodes like photo.jpg
+func decoderFor(ext string) func(io.Reader) (image.Image, error) {
+	switch strings.ToLower(ext) {
+	case ".png":
+		return png.Decode
+	case ".jpg", ".jpeg":
+		return jpeg.Decode
+	case ".webp":
+		return webp.Decode
+	case ".bmp":
+		return bmp.Decode
+	case ".tif", ".tiff":
+		return tiff.Decode
 	}
+	return nil
+}
 
-	fileSRC = os.Args[1]
-	fileDST = os.Args[2]
+const usage = `usage: ./program [options] /source/path.jpeg /destination/path.gif
+       ./program [options] -src /source/path.jpeg -dst /destination/path.gif`
 
-	return fileSRC, fileDST, nil
+// A problem with the command line. Arg is the flag or argument at fault, empty when
+// the problem is with the arguments as a whole
+type argumentError struct {
+	Arg    string
+	Reason string
 }
 
-/* ---------------- The Transformation Functions ---------------- */
-
-func convertImageHorizontal(img image.Image, width, height int, one, two, three uint8) draw.Image {
-	newImg := image.NewRGBA(image.Rect(0, 0, width, height))
-	for y := 0; y < height; y++ {
-		for x := 0; x < width; x++ {
-			col := img.At(x, y)
-			col2 := img.At(width-x, y)
-			r, g, _, a := col.RGBA()
-			_, _, b2, _ := col2.RGBA()
-			fillColor := color.RGBA{uint8(b2>>8) * one, uint8(g>>8) * two, uint8(r>>8) * three, uint8(a >> 8)}
-			newImg.SetRGBA(x, y, fillColor)
-		}
+func (e *argumentError) Error() string {
+	if e.Arg == "" {
+		return e.Reason
 	}
-	return newImg
+	return fmt.Sprintf("invalid %s: %s", e.Arg, e.Reason)
 }
 
-func convertImageVertical(img image.Image, width, height int) draw.Image {
-	newImg := image.NewRGBA(image.Rect(0, 0, width, height))
-	for y := 0; y < height; y++ {
-		for x := 0; x < width; x++ {
-			col := img.At(x, y)
-			opCol := img.At(x, height-y-1)
-			r, _, _, a := col.RGBA()
-			_, og, ob, _ := opCol.RGBA()
-			fillColor := color.RGBA{uint8(ob), uint8(og >> 8), uint8(r >> 8), uint8(a >> 8)}
-			newImg.SetRGBA(x, y, fillColor)
+// Handeling the arguments for sourcefile destination file and the optional flags.
+// Returns flag.ErrHelp after printing the help text when -h or -help is given
+func getArguments() (options, error) {
+	var opts options
+
+	flags := flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
+	flags.SetOutput(io.Discard) // Errors are reported by the caller
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "%s\n\noptions:\n", usage)
+		flags.PrintDefaults()
+	}
+	flags.StringVar(&opts.fileSRC, "src", "", "source image, instead of the first argument. - reads it from stdin with the format detected automatically")
+	flags.StringVar(&opts.fileDST, "dst", "", "destination GIF, instead of the second argument. - writes it to stdout")
+	flags.StringVar(&opts.paletteSRC, "palette-image", "", "dither the frames to the unique colors of this image")
+	flags.IntVar(&opts.delay, "delay", wackygif.DefaultDelay, "delay between frames in 100ths of a second, at least 1")
+	flags.IntVar(&opts.loop, "loop", 0, "how often the GIF repeats: 0 loops forever, -1 plays once, n plays n extra times")
+	flags.Int64Var(&opts.seed, "seed", 0, "seed for the frame order, the same seed gives the same GIF (default based on the time)")
+	flags.BoolVar(&opts.noShuffle, "no-shuffle", false, "keep the frames in the order the transformations are declared")
+	flags.Float64Var(&opts.echo, "echo", 0, "blend every frame with this much of the frame before it, from 0 up to but not including 1")
+	flags.BoolVar(&opts.force, "force", false, "write the GIF even if the destination does not end in .gif")
+	flags.IntVar(&opts.frames, "frames", 0, "number of frames, taking the first transforms or cycling through them again to fill it (default one per transform)")
+	flags.BoolVar(&opts.verbose, "v", false, "report details like the detected format of the images on stderr")
+	flags.BoolVar(&opts.list, "list", false, "print the available transforms with a description and their parameters, then exit")
+	transforms := flags.String("transforms", "", "comma separated transforms to use as frames in this order, e.g. wave,kaleidoscope,strong (default all built in ones shuffled)")
+
+	if err := flags.Parse(os.Args[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			flags.SetOutput(os.Stdout)
+			flags.Usage()
+			return options{}, err
 		}
+		return options{}, &argumentError{Reason: err.Error()}
 	}
-	return newImg
-}
 
-func adjustBrightness(img image.Image, width, height int, factor float64) draw.Image {
-	newImg := image.NewRGBA(image.Rect(0, 0, width, height))
-	for y := 0; y < height; y++ {
-		for x := 0; x < width; x++ {
-			col := img.At(x, y)
-			r, g, b, a := col.RGBA()
-			fillColor := color.RGBA{
-				uint8(clamp(int(float64(r>>8) * factor))),
-				uint8(clamp(int(float64(g>>8) * factor))),
-				uint8(clamp(int(float64(b>>8) * factor))),
-				uint8(a >> 8),
-			}
-			newImg.Set(x, y, fillColor)
-		}
+	// Listing needs no images
+	if opts.list {
+		return opts, nil
 	}
-	return newImg
-}
 
-func clamp(value int) int {
-	if value < 0 {
-		return 0
+	// Positional arguments fill in whatever was not given with -src and -dst
+	args := flags.Args()
+	if opts.fileSRC == "" && len(args) > 0 {
+		opts.fileSRC, args = args[0], args[1:]
 	}
-	if value > 255 {
-		return 255
+	if opts.fileDST == "" && len(args) > 0 {
+		opts.fileDST, args = args[0], args[1:]
 	}
-	return value
-}
-
-func waveImage(img image.Image, width, height int, amplitude, frequency float64) draw.Image {
-	newImg := image.NewRGBA(image.Rect(0, 0, width, height))
-
-	for y := 0; y < height; y++ {
-		for x := 0; x < width; x++ {
-			offset := int(amplitude * math.Sin(2*math.Pi*frequency*float64(y)/float64(height)))
-			srcX := (x + offset) % width
-			if srcX < 0 {
-				srcX += width
-			}
-			newImg.Set(x, y, img.At(srcX, y))
-		}
+	switch {
+	case opts.fileSRC == "":
+		return options{}, &argumentError{Reason: "missing source image"}
+	case opts.fileDST == "":
+		return options{}, &argumentError{Reason: "missing destination GIF"}
+	case len(args) > 0:
+		return options{}, &argumentError{Reason: fmt.Sprintf("unexpected arguments: %s", strings.Join(args, " "))}
 	}
 
-	return newImg
-}
-
-func mergeImages(img1, img2 image.Image) draw.Image {
-	bounds1 := img1.Bounds()
-	width1 := bounds1.Dx()
-	height1 := bounds1.Dy()
-	bounds2 := img2.Bounds()
-	width2 := bounds2.Dx()
-	height2 := bounds2.Dy()
-
-	minHeight := min(height1, height2)
-	minWidth := min(width1, width2)
-
-	newImg := image.NewRGBA(image.Rect(0, 0, minWidth, minHeight))
-
-	for y := 0; y < minHeight; y++ {
-		for x := 0; x < minWidth; x++ {
-			col1 := img1.At(x, y)
-			col2 := img2.At(x, y)
-			_, g1, _, _ := col1.RGBA()
-			r2, _, b2, _ := col2.RGBA()
-
-			fillColor := color.RGBA{
-				uint8(r2 >> 8),
-				uint8(g1 >> 8),
-				uint8(b2 >> 8),
-				255,
-			}
-			newImg.Set(x, y, fillColor)
-		}
+	if ext := filepath.Ext(opts.fileDST); opts.fileDST != "-" && !opts.force && !strings.EqualFold(ext, ".gif") {
+		return options{}, &argumentError{"destination", fmt.Sprintf("extension %q is not .gif, use -force to write it anyway", ext)}
 	}
-	return newImg
-}
-
-func kaleidoscopeImage(img image.Image, width, height int) draw.Image {
-	newImg := image.NewRGBA(image.Rect(0, 0, width, height))
-
-	for y := 0; y < height; y++ {
-		for x := 0; x < width; x++ {
-			if x < width/2 {
-				if y < height/2 {
-					newImg.Set(x, y, img.At(x, y))
-				} else {
-					newImg.Set(x, y, img.At(x, height-y-1))
-				}
-			} else {
-				if y < height/2 {
-					newImg.Set(x, y, img.At(width-x-1, y))
-				} else {
-					newImg.Set(x, y, img.At(width-x-1, height-y-1))
-				}
-			}
-		}
+	if opts.delay < 1 {
+		return options{}, &argumentError{"-delay", fmt.Sprintf("%d, must be at least 1", opts.delay)}
+	}
+	if opts.echo < 0 || opts.echo >= 1 {
+		return options{}, &argumentError{"-echo", fmt.Sprintf("%g, must be at least 0 and less than 1", opts.echo)}
+	}
+	if opts.loop < -1 {
+		return options{}, &argumentError{"-loop", fmt.Sprintf("%d, must be -1, 0 or a positive number of extra loops", opts.loop)}
 	}
 
-	return newImg
-}
-
-func strong(img image.Image, width, height int) draw.Image {
-	newImg := image.NewRGBA(image.Rect(0, 0, width, height))
-
-	for y := 0; y < height; y++ {
-		for x := 0; x < width; x++ {
-			col := img.At(x, y)
-			r, g, b, _ := col.RGBA()
-			r8, g8, b8 := uint8(r>>8), uint8(g>>8), uint8(b>>8)
+	// A frame count has to leave something to show
+	picked, framed := false, false
+	flags.Visit(func(f *flag.Flag) {
+		picked = picked || f.Name == "transforms"
+		framed = framed || f.Name == "frames"
+	})
+	if framed && opts.frames < 1 {
+		return options{}, &argumentError{"-frames", fmt.Sprintf("%d, must be at least 1", opts.frames)}
+	}
 
-			colorFill := color.RGBA{0, 0, 0, 255}
-			switch maxOfThree(r8, g8, b8) {
-			case 'r':
-				colorFill = color.RGBA{b8 / 2, g8 / 5, r8 / 2, 255}
-			case 'g':
-				colorFill = color.RGBA{0, b8, g8 / 2, 255}
-			case 'b':
-				colorFill = color.RGBA{g8 / 10, r8, b8 / 8, 255}
+	// Picked transforms have to exist in the registry
+	if picked {
+		valid := wackygif.ListTransforms()
+		for _, name := range strings.Split(*transforms, ",") {
+			name = strings.TrimSpace(name)
+			if name == "" {
+				continue
+			}
+			if !slices.Contains(valid, name) {
+				return options{}, &argumentError{"-transforms", fmt.Sprintf("unknown transform %q, valid transforms are: %s", name, strings.Join(valid, ", "))}
 			}
-			newImg.Set(x, y, colorFill)
+			opts.transforms = append(opts.transforms, name)
+		}
+		if len(opts.transforms) == 0 {
+			return options{}, &argumentError{"-transforms", "no transforms given"}
 		}
 	}
-	return newImg
-}
 
-func maxOfThree(r, g, b uint8) rune {
-	if r >= g && r >= b {
-		return 'r'
-	} else if g >= r && g >= b {
-		return 'g'
-	} else {
-		return 'b'
+	// Without a seed every run gets a new order
+	seeded := false
+	flags.Visit(func(f *flag.Flag) { seeded = seeded || f.Name == "seed" })
+	if !seeded {
+		opts.seed = time.Now().UnixNano()
 	}
-}
-
-func sickTwist(img image.Image, width, height int) draw.Image {
-	newImg := image.NewRGBA(image.Rect(0, 0, width, height))
 
-	for y := 0; y < height; y++ {
-		for x := 0; x < width; x++ {
-			col := img.At(x, y)
-			r, g, b, _ := col.RGBA()
-			if (x+y)%2 != 0 {
-				col = img.At(width-x, height-y)
-				g, _, b, _ = col.RGBA()
-			}
+	return opts, nil
+}
 
-			fillCol := color.RGBA{
-				uint8(r >> 8),
-				uint8(g >> 8),
-				uint8(b >> 8),
-				255,
+// Prints every registered transform on its own line with its description and parameters
+func listTransforms(w io.Writer) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, name := range wackygif.ListTransforms() {
+		description, params := "", ""
+		if t, ok := wackygif.Lookup(name); ok {
+			if d, ok := t.(wackygif.Describer); ok {
+				description = d.Description()
+				params = strings.Join(d.Params(), " ")
 			}
-			newImg.Set(x, y, fillCol)
 		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", name, description, params)
 	}
-	return newImg
+	tw.Flush()
 }
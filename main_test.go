@@ -0,0 +1,129 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDecoderForIgnoresCase(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 4, 3))
+	for i := range src.Pix {
+		src.Pix[i] = 200
+	}
+	var pngData, jpegData bytes.Buffer
+	if err := png.Encode(&pngData, src); err != nil {
+		t.Fatal(err)
+	}
+	if err := jpeg.Encode(&jpegData, src, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		ext  string
+		data []byte
+	}{
+		{".png", pngData.Bytes()},
+		{".PNG", pngData.Bytes()},
+		{".Png", pngData.Bytes()},
+		{".jpg", jpegData.Bytes()},
+		{".Jpg", jpegData.Bytes()},
+		{".JPG", jpegData.Bytes()},
+		{".JPEG", jpegData.Bytes()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ext, func(t *testing.T) {
+			decode := decoderFor(tt.ext)
+			if decode == nil {
+				t.Fatalf("no decoder for %s", tt.ext)
+			}
+			img, err := decode(bytes.NewReader(tt.data))
+			if err != nil {
+				t.Fatalf("decoding %s: %v", tt.ext, err)
+			}
+			if img.Bounds() != src.Bounds() {
+				t.Errorf("bounds %v, want %v", img.Bounds(), src.Bounds())
+			}
+		})
+	}
+}
+
+func TestDecoderForUnknown(t *testing.T) {
+	for _, ext := range []string{"", ".txt", ".GIFV"} {
+		if decoderFor(ext) != nil {
+			t.Errorf("decoderFor(%q) found a decoder", ext)
+		}
+	}
+}
+
+// Runs getArguments as if the program was started with args
+func argumentsFor(t *testing.T, args ...string) (options, error) {
+	t.Helper()
+	saved := os.Args
+	defer func() { os.Args = saved }()
+	os.Args = append([]string{"wacky-gif"}, args...)
+	return getArguments()
+}
+
+func TestDestinationExtension(t *testing.T) {
+	tests := []struct {
+		args    []string
+		wantErr string // Part of the error, empty when the arguments are fine
+	}{
+		{[]string{"in.png", "out.png"}, `".png"`},
+		{[]string{"in.png", "out.JPEG"}, `".JPEG"`},
+		{[]string{"in.png", "out"}, `""`},
+		{[]string{"in.png", "out.gif"}, ""},
+		{[]string{"in.png", "out.GIF"}, ""},
+		{[]string{"in.png", "-"}, ""},
+		{[]string{"-force", "in.png", "out.png"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+			_, err := argumentsFor(t, tt.args...)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			var argErr *argumentError
+			if !errors.As(err, &argErr) || argErr.Arg != "destination" {
+				t.Fatalf("error %v, want an argumentError for the destination", err)
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error %q does not name the extension %s", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// A PNG saved as .jpg fails the jpeg decoder and is sniffed instead, which gets logged
+func TestLoadImageLogsSniffedFormat(t *testing.T) {
+	var data bytes.Buffer
+	if err := png.Encode(&data, image.NewRGBA(image.Rect(0, 0, 3, 2))); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "photo.jpg")
+	if err := os.WriteFile(path, data.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var log bytes.Buffer
+	img, err := loadImage(path, &log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if img.Bounds().Dx() != 3 || img.Bounds().Dy() != 2 {
+		t.Errorf("bounds %v, want 3x2", img.Bounds())
+	}
+	if want := path + " decoded as png\n"; log.String() != want {
+		t.Errorf("logged %q, want %q", log.String(), want)
+	}
+}
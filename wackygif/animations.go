@@ -0,0 +1,430 @@
+package wackygif
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"math/rand"
+	"sort"
+)
+
+/* ---------------- The Animated Transformation Functions ---------------- */
+
+// Animated transformations also get the index of the frame being rendered and the
+// total number of frames, so the effect can progress over the length of the GIF
+type animation func(img image.Image, width, height, frame, frames int) draw.Image
+
+// Blends a diagonal rainbow over the image, the rainbow moves one full cycle over all the frames
+func rainbowSweep(img image.Image, width, height, frame, frames int, strength float64) draw.Image {
+	newImg := image.NewRGBA(image.Rect(0, 0, width, height))
+	strength = math.Max(0, math.Min(strength, 1))
+	phase := float64(frame) / float64(max(frames, 1))
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			r, g, b, a := img.At(x, y).RGBA()
+			hue := float64(x+y)/float64(width+height) + phase
+			hue = 360 * (hue - math.Floor(hue))
+			rr, rg, rb := hsvToRGB(hue, 1, 1)
+
+			fillColor := color.RGBA{
+				uint8(float64(r>>8)*(1-strength) + rr*strength),
+				uint8(float64(g>>8)*(1-strength) + rg*strength),
+				uint8(float64(b>>8)*(1-strength) + rb*strength),
+				uint8(a >> 8),
+			}
+			newImg.SetRGBA(x, y, fillColor)
+		}
+	}
+	return newImg
+}
+
+// Hue in degrees, saturation and value in 0-1. Returns the channels in the range 0-255
+func hsvToRGB(h, s, v float64) (r, g, b float64) {
+	h = math.Mod(h, 360)
+	if h < 0 {
+		h += 360
+	}
+	c := v * s
+	hp := h / 60
+	x := c * (1 - math.Abs(math.Mod(hp, 2)-1))
+	switch {
+	case hp < 1:
+		r, g, b = c, x, 0
+	case hp < 2:
+		r, g, b = x, c, 0
+	case hp < 3:
+		r, g, b = 0, c, x
+	case hp < 4:
+		r, g, b = 0, x, c
+	case hp < 5:
+		r, g, b = x, 0, c
+	default:
+		r, g, b = c, 0, x
+	}
+	m := v - c
+	return (r + m) * 255, (g + m) * 255, (b + m) * 255
+}
+
+// Wobbles the whole image with a slow sine warp that completes one cycle over all the frames
+func jelly(img image.Image, width, height, frame, frames int, amplitude float64) draw.Image {
+	newImg := image.NewRGBA(image.Rect(0, 0, width, height))
+	phase := 2 * math.Pi * float64(frame) / float64(max(frames, 1))
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			srcX := float64(x) + amplitude*math.Sin(2*math.Pi*float64(y)/float64(height)+phase)
+			srcY := float64(y) + amplitude*math.Sin(2*math.Pi*float64(x)/float64(width)+phase)
+			newImg.SetRGBA(x, y, bilinear(img, width, height, srcX, srcY))
+		}
+	}
+	return newImg
+}
+
+// Sweeps a bright horizontal band from the top of the image on the first frame to the bottom on the last
+func scanBeam(img image.Image, width, height, frame, frames int, beamWidth int, brightness float64) draw.Image {
+	newImg := image.NewRGBA(image.Rect(0, 0, width, height))
+	center := 0
+	if frames > 1 {
+		center = (height - 1) * frame / (frames - 1)
+	}
+	half := float64(max(beamWidth, 1)) / 2
+
+	for y := 0; y < height; y++ {
+		// Fades out towards the edges of the beam
+		boost := brightness * math.Max(0, 1-math.Abs(float64(y-center))/half)
+		for x := 0; x < width; x++ {
+			r, g, b, a := img.At(x, y).RGBA()
+			fillColor := color.RGBA{
+				uint8(clamp(int(float64(r>>8) + boost))),
+				uint8(clamp(int(float64(g>>8) + boost))),
+				uint8(clamp(int(float64(b>>8) + boost))),
+				uint8(a >> 8),
+			}
+			newImg.SetRGBA(x, y, fillColor)
+		}
+	}
+	return newImg
+}
+
+// Pushes every pixel away from the center, further for every frame. The gaps left behind are
+// filled with the background color, by the last frame the pixels have traveled twice their distance
+func explode(img image.Image, width, height, frame, frames int, background color.RGBA) draw.Image {
+	newImg := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(newImg, newImg.Bounds(), image.NewUniform(background), image.Point{}, draw.Src)
+	cx, cy := float64(width-1)/2, float64(height-1)/2
+	scale := 1 + float64(frame)/float64(max(frames-1, 1))
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			dx := int(math.Round(cx + (float64(x)-cx)*scale))
+			dy := int(math.Round(cy + (float64(y)-cy)*scale))
+			if dx < 0 || dy < 0 || dx >= width || dy >= height {
+				continue
+			}
+			newImg.Set(dx, dy, img.At(x, y))
+		}
+	}
+	return newImg
+}
+
+// Lets the columns of pixels fall off the bottom of the image one after another,
+// revealing the background. Every column starts falling at its own time and is gone by the last frame
+func pixelRain(img image.Image, width, height, frame, frames int, background color.RGBA) draw.Image {
+	newImg := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(newImg, newImg.Bounds(), image.NewUniform(background), image.Point{}, draw.Src)
+	progress := float64(frame) / float64(max(frames-1, 1))
+
+	// Same start times on every frame so the columns keep falling instead of jumping around
+	rng := rand.New(rand.NewSource(int64(width)))
+	for x := 0; x < width; x++ {
+		start := rng.Float64() * 0.5
+		fall := 0
+		if progress > start {
+			t := (progress - start) / (1 - start)
+			fall = int(t * t * float64(height)) // Speeds up like it is actually falling
+		}
+		for y := height - 1; y >= fall; y-- {
+			newImg.Set(x, y, img.At(x, y-fall))
+		}
+	}
+	return newImg
+}
+
+// Spins the wedge that feeds the radial kaleidoscope one full turn over all the frames
+func kaleidoRotate(img image.Image, width, height, frame, frames int, segments int) draw.Image {
+	offset := 360 * float64(frame) / float64(max(frames, 1))
+	return kaleidoscopeWedge(img, width, height, segments, offset)
+}
+
+// Types the caption out over the bottom of the image one character at a time. The first
+// frame shows none of it and the typing is spread over the loop, but never goes faster
+// than a character a frame, so with fewer frames than characters the caption is cut short
+func typeReveal(img image.Image, width, height, frame, frames int, caption string) draw.Image {
+	newImg := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(newImg, newImg.Bounds(), img, image.Point{}, draw.Src)
+
+	chars := []rune(caption)
+	shown := len(chars)
+	if frames > 1 {
+		frame = min(frame, frames-1)
+		shown = min(frame*len(chars)/(frames-1), frame)
+	}
+	drawCaption(newImg, caption, shown)
+	return newImg
+}
+
+// Mirrors the image into four quadrants and zooms further into the result every frame,
+// ending at twice the size on the last frame
+func mirrorZoom(img image.Image, width, height, frame, frames int) draw.Image {
+	scale := 1 + float64(frame)/float64(max(frames-1, 1))
+	return zoom(kaleidoscopeImage(img, width, height), width, height, scale)
+}
+
+// VHS playback with bad tracking. Every frame a band of noise jumps to a new height, the
+// colors in it smear sideways and the whole picture jitters up and down a little.
+// intensity goes from 0 (clean tape) to 1
+func vhsTracking(img image.Image, width, height, frame, frames int, intensity float64) draw.Image {
+	newImg := image.NewRGBA(image.Rect(0, 0, width, height))
+	intensity = math.Max(0, math.Min(intensity, 1))
+	if intensity == 0 {
+		draw.Draw(newImg, newImg.Bounds(), img, image.Point{}, draw.Src)
+		return newImg
+	}
+
+	// Seeded by the frame so the same frame always looks the same
+	rng := rand.New(rand.NewSource(int64(frame) + 1))
+	jitterRange := int(intensity*3) + 1
+	jitter := rng.Intn(2*jitterRange+1) - jitterRange
+	bandHeight := max(1, height/12)
+	bandTop := rng.Intn(max(height-bandHeight, 1))
+	bleed := int(intensity*8) + 1
+
+	for y := 0; y < height; y++ {
+		srcY := max(0, min(y+jitter, height-1))
+		inBand := y >= bandTop && y < bandTop+bandHeight
+		tear := 0
+		if inBand {
+			tear = int(float64(rng.Intn(width/10+1)) * intensity)
+		}
+
+		for x := 0; x < width; x++ {
+			srcX := max(0, min(x-tear, width-1))
+			r, g, b, a := img.At(srcX, srcY).RGBA()
+			if !inBand {
+				newImg.SetRGBA(x, y, color.RGBA{uint8(r >> 8), uint8(g >> 8), uint8(b >> 8), uint8(a >> 8)})
+				continue
+			}
+
+			// Red lags behind and the band gets static mixed in
+			r, _, _, _ = img.At(max(0, srcX-bleed), srcY).RGBA()
+			noise := rng.Float64() * 255 * intensity
+			mix := func(c uint32) uint8 {
+				return uint8(float64(c>>8)*(1-intensity/2) + noise*intensity/2)
+			}
+			newImg.SetRGBA(x, y, color.RGBA{mix(r), mix(g), mix(b), uint8(a >> 8)})
+		}
+	}
+	return newImg
+}
+
+// Rolls the picture upwards like an analog TV losing vertical hold, one full image height
+// over all the frames. The rows around the seam get torn apart interlace style
+func vRoll(img image.Image, width, height, frame, frames int) draw.Image {
+	newImg := image.NewRGBA(image.Rect(0, 0, width, height))
+	offset := height * frame / max(frames, 1) % max(height, 1)
+	tearRows := max(2, height/40)
+	tear := max(1, width/30)
+
+	for y := 0; y < height; y++ {
+		srcY := (y + offset) % height
+
+		// The seam is where the bottom of the image meets the top
+		shift := 0
+		seam := height - offset
+		if offset != 0 && y >= seam-tearRows && y < seam+tearRows {
+			if y%2 == 0 {
+				shift = tear
+			} else {
+				shift = -tear
+			}
+		}
+
+		for x := 0; x < width; x++ {
+			srcX := max(0, min(x-shift, width-1))
+			newImg.Set(x, y, img.At(srcX, srcY))
+		}
+	}
+	return newImg
+}
+
+// Slowly zooms in and out like the image is breathing, from its normal size on the
+// first frame to the peak zoom halfway through and back again
+func breathe(img image.Image, width, height, frame, frames int) draw.Image {
+	const peak = 1.2
+
+	phase := 2 * math.Pi * float64(frame) / float64(max(frames, 1))
+	scale := 1 + (peak-1)*(1-math.Cos(phase))/2
+	return zoom(img, width, height, scale)
+}
+
+// Sci-fi scanner grid. During the first half of the frames vertical laser lines sweep
+// to the right, during the second half horizontal lines sweep down. The lines are
+// spacing pixels apart and added on top of the image with blend strength
+func laserGrid(img image.Image, width, height, frame, frames int, spacing int, blend float64) draw.Image {
+	newImg := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(newImg, newImg.Bounds(), img, image.Point{}, draw.Src)
+	laser := color.RGBA{40, 255, 90, 255}
+	spacing = max(spacing, 2)
+
+	half := max((frames+1)/2, 1)
+	vertical := frame < half
+	step := frame % half
+	offset := step * spacing / half
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			pos := y
+			if vertical {
+				pos = x
+			}
+			if (pos-offset)%spacing != 0 {
+				continue
+			}
+			cur := newImg.RGBAAt(x, y)
+			newImg.SetRGBA(x, y, color.RGBA{
+				uint8(clamp(int(cur.R) + int(float64(laser.R)*blend))),
+				uint8(clamp(int(cur.G) + int(float64(laser.G)*blend))),
+				uint8(clamp(int(cur.B) + int(float64(laser.B)*blend))),
+				cur.A,
+			})
+		}
+	}
+	return newImg
+}
+
+// Bright pixels are heavy and sink like sand. Every pixel slides from where it started
+// towards the place it ends up when the column is sorted by brightness, and on the last
+// frame every column has settled with its brightest pixels at the bottom
+func gravity(img image.Image, width, height, frame, frames int) draw.Image {
+	newImg := image.NewRGBA(image.Rect(0, 0, width, height))
+	progress := float64(frame) / float64(max(frames-1, 1))
+
+	column := make([]color.RGBA, height)
+	weight := make([]float64, height)
+	target := make([]int, height)
+	order := make([]int, height)
+	position := make([]float64, height)
+	for x := 0; x < width; x++ {
+		for y := 0; y < height; y++ {
+			column[y] = color.RGBAModel.Convert(img.At(x, y)).(color.RGBA)
+			weight[y] = luma(column[y])
+			order[y] = y
+		}
+
+		// Where every pixel ends up once the column has settled
+		sort.SliceStable(order, func(i, j int) bool { return weight[order[i]] < weight[order[j]] })
+		for rank, y := range order {
+			target[y] = rank
+		}
+
+		// Part of the way there, the pixels keep their relative order along the way
+		for y := 0; y < height; y++ {
+			position[y] = float64(y) + float64(target[y]-y)*progress
+			order[y] = y
+		}
+		sort.SliceStable(order, func(i, j int) bool {
+			a, b := order[i], order[j]
+			if position[a] != position[b] {
+				return position[a] < position[b]
+			}
+			return weight[a] < weight[b]
+		})
+
+		for y, src := range order {
+			newImg.SetRGBA(x, y, column[src])
+		}
+	}
+	return newImg
+}
+
+// Reception breaking down and coming back. Bands of rows tear sideways and static creeps
+// in, starting clean on the first frame, worst halfway through and recovering towards the end
+func signalJam(img image.Image, width, height, frame, frames int) draw.Image {
+	newImg := image.NewRGBA(image.Rect(0, 0, width, height))
+	intensity := math.Sin(math.Pi * float64(frame) / float64(max(frames, 1)))
+	maxTear := float64(width) / 8
+
+	// The tear pattern stays the same and only grows, the static changes every frame
+	pattern := rand.New(rand.NewSource(1))
+	static := rand.New(rand.NewSource(int64(frame) + 1))
+	bandHeight := max(1, height/24)
+	tear := 0.0
+
+	for y := 0; y < height; y++ {
+		if y%bandHeight == 0 {
+			tear = pattern.Float64()*2 - 1
+		}
+		shift := int(tear * maxTear * intensity)
+		for x := 0; x < width; x++ {
+			srcX := max(0, min(x-shift, width-1))
+			r, g, b, a := img.At(srcX, y).RGBA()
+			noise := static.Float64() * 255
+			mix := func(c uint32) uint8 {
+				return uint8(float64(c>>8)*(1-intensity/2) + noise*intensity/2)
+			}
+			newImg.SetRGBA(x, y, color.RGBA{mix(r), mix(g), mix(b), uint8(a >> 8)})
+		}
+	}
+	return newImg
+}
+
+// Black and white with a cutoff that swings up and down once over all the frames,
+// so the bright and dark areas grow and shrink in a pulse
+func thresholdPulse(img image.Image, width, height, frame, frames int) draw.Image {
+	phase := 2 * math.Pi * float64(frame) / float64(max(frames, 1))
+	cutoff := uint8(math.Round(128 + 96*math.Sin(phase)))
+	return threshold(img, width, height, cutoff)
+}
+
+// Translucent rain falling over a slightly blurred background. Density is the number of
+// streaks for every 100 pixels of the image, 0 leaves the image untouched. Angle tilts the
+// rain in degrees from straight down, up to 60 either way. The rain falls one image height
+// per loop and drifts sideways by the whole number of image widths closest to its tilt,
+// so the last frame leads back into the first
+func rainStreaks(img image.Image, width, height, frame, frames int, density, angle float64) draw.Image {
+	count := int(density * float64(width*height) / 100)
+	if count <= 0 {
+		newImg := image.NewRGBA(image.Rect(0, 0, width, height))
+		draw.Draw(newImg, newImg.Bounds(), img, image.Point{}, draw.Src)
+		return newImg
+	}
+	newImg := separableBlur(img, width, height, gaussianKernel(1))
+	rain := color.RGBA{200, 210, 230, 255}
+	const alpha = 0.35
+
+	angle = math.Max(-60, math.Min(angle, 60))
+	dx := math.Sin(angle * math.Pi / 180)
+	dy := math.Cos(angle * math.Pi / 180)
+	progress := float64(frame) / float64(max(frames, 1))
+	fall := float64(height) * progress
+	drift := math.Round(float64(height)*dx/dy/float64(width)) * float64(width) * progress
+
+	// The same streaks every frame, only moved further along
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < count; i++ {
+		startX := rng.Float64()*float64(width) + drift
+		startY := rng.Float64()*float64(height) + fall
+		length := float64(height)/12 + rng.Float64()*float64(height)/12
+
+		for t := 0.0; t < length; t++ {
+			x := (int(math.Floor(startX+dx*t))%width + width) % width
+			y := (int(math.Floor(startY+dy*t))%height + height) % height
+			cur := newImg.RGBAAt(x, y)
+			mix := func(c, r uint8) uint8 { return uint8(float64(c)*(1-alpha) + float64(r)*alpha) }
+			newImg.SetRGBA(x, y, color.RGBA{mix(cur.R, rain.R), mix(cur.G, rain.G), mix(cur.B, rain.B), cur.A})
+		}
+	}
+	return newImg
+}
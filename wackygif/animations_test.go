@@ -0,0 +1,469 @@
+package wackygif
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"testing"
+)
+
+// Largest difference of any channel between the two colors
+func colorDiff(a, b color.RGBA) int {
+	diff := 0
+	for _, d := range []int{int(a.R) - int(b.R), int(a.G) - int(b.G), int(a.B) - int(b.B), int(a.A) - int(b.A)} {
+		diff = max(diff, d, -d)
+	}
+	return diff
+}
+
+// Largest difference of any channel between the two images
+func maxDiff(a, b image.Image) int {
+	diff := 0
+	bounds := a.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			diff = max(diff, colorDiff(rgbaAt(a, x, y), rgbaAt(b, x, y)))
+		}
+	}
+	return diff
+}
+
+func TestRainbowSweep(t *testing.T) {
+	const width, height, frames = 24, 16, 40
+	src := noiseImage(width, height, 40)
+	for frame := 0; frame < frames; frame += 7 {
+		assertSameImage(t, rainbowSweep(src, width, height, frame, frames, 0), src)
+	}
+
+	// With width+height equal to the frame count the rainbow moves one pixel a frame
+	prev := rainbowSweep(src, width, height, 3, frames, 1)
+	next := rainbowSweep(src, width, height, 4, frames, 1)
+	for y := 0; y < height; y++ {
+		for x := 0; x+1 < width; x++ {
+			a, b := rgbaAt(next, x, y), rgbaAt(prev, x+1, y)
+			if colorDiff(a, b) > 1 {
+				t.Fatalf("pixel (%d, %d) is %v on the next frame, want %v from one pixel further", x, y, a, b)
+			}
+		}
+	}
+}
+
+func TestJelly(t *testing.T) {
+	const size, frames = 20, 8
+	src := noiseImage(size, size, 41)
+	for frame := 0; frame < frames; frame++ {
+		assertSameImage(t, jelly(src, size, size, frame, frames, 0), src)
+	}
+
+	for frame := 0; frame < frames; frame++ {
+		a := jelly(src, size, size, frame, frames, 3)
+		b := jelly(src, size, size, frame+frames, frames, 3)
+		if d := maxDiff(a, b); d > 1 {
+			t.Errorf("frame %d and %d differ by %d, want the warp to repeat", frame, frame+frames, d)
+		}
+	}
+}
+
+func TestScanBeam(t *testing.T) {
+	const size, frames, beam = 30, 5, 4
+	gray := uniformImage(size, size, color.RGBA{100, 100, 100, 255})
+
+	lit := func(img image.Image) []int {
+		var rows []int
+		for y := 0; y < size; y++ {
+			if rgbaAt(img, 0, y) != gray.RGBAAt(0, y) {
+				rows = append(rows, y)
+			}
+		}
+		return rows
+	}
+	first := lit(scanBeam(gray, size, size, 0, frames, beam, 100))
+	last := lit(scanBeam(gray, size, size, frames-1, frames, beam, 100))
+	if len(first) == 0 || first[0] != 0 || first[len(first)-1] > beam {
+		t.Errorf("first frame lights rows %v, want the beam at the top", first)
+	}
+	if len(last) == 0 || last[len(last)-1] != size-1 || last[0] < size-1-beam {
+		t.Errorf("last frame lights rows %v, want the beam at the bottom", last)
+	}
+}
+
+func TestExplode(t *testing.T) {
+	const size, frames = 21, 5
+	black := color.RGBA{0, 0, 0, 255}
+	src := noiseImage(size, size, 42)
+	assertSameImage(t, explode(src, size, size, 0, frames, black), src)
+
+	// A marker right of the center gets pushed further right every frame
+	marker := uniformImage(size, size, black)
+	marker.SetRGBA(14, 10, color.RGBA{255, 255, 255, 255})
+	prev := 14
+	for frame := 1; frame < frames; frame++ {
+		out := explode(marker, size, size, frame, frames, black)
+		at := -1
+		for x := 0; x < size; x++ {
+			if rgbaAt(out, x, 10) != black {
+				at = x
+			}
+		}
+		if at <= prev {
+			t.Fatalf("frame %d has the marker at %d, want it past %d", frame, at, prev)
+		}
+		prev = at
+	}
+}
+
+func TestPixelRain(t *testing.T) {
+	const size, frames = 20, 6
+	background := color.RGBA{0, 0, 0, 0}
+	src := noiseImage(size, size, 43)
+	assertSameImage(t, pixelRain(src, size, size, 0, frames, background), src)
+
+	// Count how much of every column has fallen away, which only ever grows
+	fallen := make([]int, size)
+	for frame := 1; frame < frames; frame++ {
+		out := pixelRain(src, size, size, frame, frames, background)
+		total := 0
+		for x := 0; x < size; x++ {
+			n := 0
+			for y := 0; y < size; y++ {
+				if rgbaAt(out, x, y) == background {
+					n++
+				}
+			}
+			if n < fallen[x] {
+				t.Fatalf("column %d went back up on frame %d", x, frame)
+			}
+			fallen[x] = n
+			total += n
+		}
+		if frame == frames-1 && total < size*size*9/10 {
+			t.Errorf("last frame is %d/%d background, want most of it", total, size*size)
+		}
+	}
+}
+
+func TestKaleidoRotate(t *testing.T) {
+	const size, frames, segments = 31, 6, 6
+	src := noiseImage(size, size, 44)
+	assertSameImage(t, kaleidoRotate(src, size, size, 0, frames, segments), kaleidoscopeN(src, size, size, segments))
+
+	for frame := 0; frame < frames; frame++ {
+		a := kaleidoRotate(src, size, size, frame, frames, segments)
+		b := kaleidoRotate(src, size, size, frame+frames, frames, segments)
+		if d := maxDiff(a, b); d > 1 {
+			t.Errorf("frame %d and %d differ by %d, want a full turn to repeat", frame, frame+frames, d)
+		}
+	}
+}
+
+// Number of characters of caption typeReveal drew over src, -1 when out doesn't match any
+func captionShown(out image.Image, src *image.RGBA, caption string) int {
+	for n := 0; n <= len(caption); n++ {
+		img := image.NewRGBA(src.Bounds())
+		copy(img.Pix, src.Pix)
+		drawCaption(img, caption, n)
+		if !differentImages(out, img) {
+			return n
+		}
+	}
+	return -1
+}
+
+func TestTypeReveal(t *testing.T) {
+	const width, height, frames, caption = 120, 80, 7, "HI!"
+	src := noiseImage(width, height, 45)
+	assertSameImage(t, typeReveal(src, width, height, 0, frames, caption), src)
+
+	prev := 0
+	for frame := 0; frame < frames; frame++ {
+		shown := captionShown(typeReveal(src, width, height, frame, frames, caption), src, caption)
+		if shown < prev || shown > prev+1 {
+			t.Fatalf("frame %d shows %d characters after %d", frame, shown, prev)
+		}
+		prev = shown
+	}
+	if prev != len(caption) {
+		t.Errorf("last frame shows %d characters, want all %d", prev, len(caption))
+	}
+}
+
+// With fewer frames than characters it still types one character a frame
+func TestTypeRevealShortLoop(t *testing.T) {
+	const width, height, frames, caption = 160, 80, 4, "TYPEWRITER"
+	src := noiseImage(width, height, 47)
+	for frame := 0; frame < frames; frame++ {
+		if shown := captionShown(typeReveal(src, width, height, frame, frames, caption), src, caption); shown != frame {
+			t.Errorf("frame %d shows %d characters, want %d", frame, shown, frame)
+		}
+	}
+}
+
+func TestMirrorZoom(t *testing.T) {
+	const size, frames = 24, 5
+	src := noiseImage(size, size, 46)
+	mirrored := kaleidoscopeImage(src, size, size)
+	assertSameImage(t, mirrorZoom(src, size, size, 0, frames), mirrored)
+
+	for frame := 1; frame < frames; frame++ {
+		want := zoom(mirrored, size, size, 1+float64(frame)/(frames-1))
+		assertSameImage(t, mirrorZoom(src, size, size, frame, frames), want)
+	}
+}
+
+func TestVHSTracking(t *testing.T) {
+	const size, frames = 36, 4
+	src := noiseImage(size, size, 47)
+	for frame := 0; frame < frames; frame++ {
+		assertSameImage(t, vhsTracking(src, size, size, frame, frames, 0), src)
+	}
+
+	// On a flat image the jitter does nothing, so only the noise band differs
+	gray := uniformImage(size, size, color.RGBA{128, 128, 128, 255})
+	band := func(frame int) (top int) {
+		out := vhsTracking(gray, size, size, frame, frames, 1)
+		for y := 0; y < size; y++ {
+			for x := 0; x < size; x++ {
+				if rgbaAt(out, x, y) != gray.RGBAAt(x, y) {
+					return y
+				}
+			}
+		}
+		return -1
+	}
+	tops := make(map[int]bool)
+	for frame := 0; frame < frames; frame++ {
+		top := band(frame)
+		if top == -1 {
+			t.Fatalf("frame %d has no tracking band", frame)
+		}
+		tops[top] = true
+	}
+	if len(tops) < 2 {
+		t.Error("the tracking band stays at the same height on every frame")
+	}
+}
+
+func TestVRoll(t *testing.T) {
+	const width, height, frames = 12, 40, 8
+	src := noiseImage(width, height, 48)
+	assertSameImage(t, vRoll(src, width, height, 0, frames), src)
+	assertSameImage(t, vRoll(src, width, height, frames, frames), src)
+
+	// Rows away from the torn seam are the source moved up by height/frames a frame
+	tearRows := max(2, height/40)
+	for frame := 1; frame < frames; frame++ {
+		out := vRoll(src, width, height, frame, frames)
+		offset := height * frame / frames
+		seam := height - offset
+		for y := 0; y < height; y++ {
+			if y >= seam-tearRows && y < seam+tearRows {
+				continue
+			}
+			for x := 0; x < width; x++ {
+				if got, want := rgbaAt(out, x, y), src.RGBAAt(x, (y+offset)%height); got != want {
+					t.Fatalf("frame %d pixel (%d, %d) is %v, want %v", frame, x, y, got, want)
+				}
+			}
+		}
+	}
+}
+
+func TestBreathe(t *testing.T) {
+	const size, frames = 20, 8
+	src := noiseImage(size, size, 49)
+	assertSameImage(t, breathe(src, size, size, 0, frames), src)
+	assertSameImage(t, breathe(src, size, size, frames/2, frames), zoom(src, size, size, 1.2))
+
+	for frame := 0; frame < frames; frame++ {
+		if d := maxDiff(breathe(src, size, size, frame, frames), breathe(src, size, size, frame+frames, frames)); d > 1 {
+			t.Errorf("frame %d and %d differ by %d", frame, frame+frames, d)
+		}
+	}
+}
+
+func TestLaserGrid(t *testing.T) {
+	const size, frames, spacing = 24, 8, 8
+	src := noiseImage(size, size, 50)
+	for frame := 0; frame < frames; frame++ {
+		assertSameImage(t, laserGrid(src, size, size, frame, frames, spacing, 0), src)
+	}
+
+	// During the first half the vertical lines move right a bit every frame
+	black := uniformImage(size, size, color.RGBA{0, 0, 0, 255})
+	prev := -1
+	for frame := 0; frame < frames/2; frame++ {
+		out := laserGrid(black, size, size, frame, frames, spacing, 1)
+		first := -1
+		for x := 0; x < size && first == -1; x++ {
+			if rgbaAt(out, x, 0).G != 0 {
+				first = x
+			}
+		}
+		if first <= prev {
+			t.Fatalf("frame %d has its first line at %d, want it past %d", frame, first, prev)
+		}
+		prev = first
+	}
+}
+
+func TestGravity(t *testing.T) {
+	const size, frames = 16, 6
+	src := noiseImage(size, size, 51)
+	assertSameImage(t, gravity(src, size, size, 0, frames), src)
+
+	// Average height of the brightness, weighted by luma, sinks every frame
+	centerOfMass := func(img image.Image) float64 {
+		sum, weight := 0.0, 0.0
+		for y := 0; y < size; y++ {
+			for x := 0; x < size; x++ {
+				l := luma(img.At(x, y))
+				sum += l * float64(y)
+				weight += l
+			}
+		}
+		return sum / weight
+	}
+	prev := centerOfMass(src)
+	var last image.Image
+	for frame := 1; frame < frames; frame++ {
+		last = gravity(src, size, size, frame, frames)
+		com := centerOfMass(last)
+		if com < prev {
+			t.Fatalf("frame %d has its bright pixels at %.2f, above %.2f before", frame, com, prev)
+		}
+		prev = com
+	}
+	for x := 0; x < size; x++ {
+		for y := 1; y < size; y++ {
+			if luma(last.At(x, y)) < luma(last.At(x, y-1)) {
+				t.Fatalf("column %d has not settled at row %d on the last frame", x, y)
+			}
+		}
+	}
+}
+
+func TestSignalJam(t *testing.T) {
+	const size, frames = 24, 12
+	src := noiseImage(size, size, 52)
+	assertSameImage(t, signalJam(src, size, size, 0, frames), src)
+
+	// How far every frame is from the clean picture grows towards the middle
+	distance := func(img image.Image) float64 {
+		total := 0.0
+		for y := 0; y < size; y++ {
+			for x := 0; x < size; x++ {
+				a, b := rgbaAt(img, x, y), src.RGBAAt(x, y)
+				total += math.Abs(float64(a.R)-float64(b.R)) + math.Abs(float64(a.G)-float64(b.G)) + math.Abs(float64(a.B)-float64(b.B))
+			}
+		}
+		return total
+	}
+	prev := 0.0
+	for frame := 1; frame <= frames/2; frame++ {
+		out := signalJam(src, size, size, frame, frames)
+		if out.Bounds() != src.Bounds() {
+			t.Fatalf("frame %d has bounds %v", frame, out.Bounds())
+		}
+		d := distance(out)
+		if d <= prev {
+			t.Fatalf("frame %d is %.0f away from clean, not more than %.0f before", frame, d, prev)
+		}
+		prev = d
+	}
+}
+
+func TestThresholdPulse(t *testing.T) {
+	const size, frames = 16, 8
+	src := noiseImage(size, size, 53)
+
+	whiteShare := func(img image.Image) int {
+		n := 0
+		for y := 0; y < size; y++ {
+			for x := 0; x < size; x++ {
+				if rgbaAt(img, x, y).R == 255 {
+					n++
+				}
+			}
+		}
+		return n
+	}
+	prev := size*size + 1
+	for cutoff := 0; cutoff <= 255; cutoff += 15 {
+		n := whiteShare(threshold(src, size, size, uint8(cutoff)))
+		if n > prev {
+			t.Fatalf("cutoff %d leaves %d white pixels, more than %d at a lower cutoff", cutoff, n, prev)
+		}
+		prev = n
+	}
+
+	for frame := 0; frame < frames; frame++ {
+		assertSameImage(t, thresholdPulse(src, size, size, frame+frames, frames), thresholdPulse(src, size, size, frame, frames))
+	}
+}
+
+func TestRainStreaks(t *testing.T) {
+	const size, frames = 40, 8
+	src := noiseImage(size, size, 56)
+	assertSameImage(t, rainStreaks(src, size, size, 0, frames, 0, 10), src)
+
+	// The blur leaves a flat background as it is, so only the rain changes. Falling straight
+	// down the streaks move size/frames rows from one frame to the next
+	gray := uniformImage(size, size, color.RGBA{60, 60, 60, 255})
+	prev := rainStreaks(gray, size, size, 2, frames, 1, 0)
+	next := rainStreaks(gray, size, size, 3, frames, 1, 0)
+	if !differentImages(prev, gray) {
+		t.Fatal("no streaks drawn")
+	}
+	const fall = size / frames
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			if got, want := rgbaAt(next, x, y), rgbaAt(prev, x, (y-fall+size)%size); got != want {
+				t.Fatalf("pixel (%d, %d) is %v on the next frame, want %v from %d rows up", x, y, got, want, fall)
+			}
+		}
+	}
+}
+
+// Tilted rain has to loop too, the frame after the last looks like the first
+func TestRainStreaksLoops(t *testing.T) {
+	const width, height, frames = 12, 40, 6
+	src := noiseImage(width, height, 57)
+	for _, angle := range []float64{15, -40, 60} {
+		first := rainStreaks(src, width, height, 0, frames, 2, angle)
+		assertSameImage(t, rainStreaks(src, width, height, frames, frames, 2, angle), first)
+		if !differentImages(rainStreaks(src, width, height, 1, frames, 2, angle), first) {
+			t.Errorf("angle %g: the rain did not move", angle)
+		}
+	}
+}
+
+func TestAnimationAsTransform(t *testing.T) {
+	src := noiseImage(10, 10, 54)
+	a := NewAnimation("test", func(img image.Image, width, height, frame, frames int) draw.Image {
+		return scanBeam(img, width, height, frame, frames, 2, 100)
+	})
+	assertSameImage(t, a.Apply(src, 10, 10), scanBeam(src, 10, 10, 0, 1, 2, 100))
+	assertSameImage(t, a.ApplyFrame(src, 10, 10, 3, 4), scanBeam(src, 10, 10, 3, 4, 2, 100))
+
+	if _, ok := describe(a, "test").(Animated); !ok {
+		t.Error("describing an animation loses ApplyFrame")
+	}
+}
+
+// GenerateGIF hands every frame its index, so an animation gives a different frame each time
+func TestGenerateGIFAnimates(t *testing.T) {
+	src := noiseImage(20, 20, 55)
+	g, err := GenerateGIF(src, Options{Transforms: []string{"rainbow-sweep"}, Frames: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(g.Image) != 4 {
+		t.Fatalf("got %d frames, want 4", len(g.Image))
+	}
+	for i := 1; i < len(g.Image); i++ {
+		if !differentImages(g.Image[i-1], g.Image[i]) {
+			t.Errorf("frame %d is the same as the one before it", i)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package wackygif
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	"sort"
+	"sync"
+)
+
+// A named effect that turns the source image into one frame of the GIF
+type Transform interface {
+	Name() string
+	Apply(img image.Image, width, height int) draw.Image
+}
+
+// Turns a plain function into a Transform, handy for registering your own effects
+func NewTransform(name string, apply func(img image.Image, width, height int) draw.Image) Transform {
+	return funcTransform{name, apply}
+}
+
+type funcTransform struct {
+	name  string
+	apply func(image.Image, int, int) draw.Image
+}
+
+func (t funcTransform) Name() string { return t.name }
+
+func (t funcTransform) Apply(img image.Image, width, height int) draw.Image {
+	return t.apply(img, width, height)
+}
+
+// A transform that changes over the length of the GIF. GenerateGIF renders it with
+// ApplyFrame, passing the index of the frame and the total number of frames
+type Animated interface {
+	Transform
+	ApplyFrame(img image.Image, width, height, frame, frames int) draw.Image
+}
+
+// Turns a frame aware function into a Transform. Used as a plain Transform it renders
+// the first frame of a one frame GIF
+func NewAnimation(name string, apply func(img image.Image, width, height, frame, frames int) draw.Image) Animated {
+	return animationTransform{name, apply}
+}
+
+type animationTransform struct {
+	name  string
+	apply animation
+}
+
+func (t animationTransform) Name() string { return t.name }
+
+func (t animationTransform) Apply(img image.Image, width, height int) draw.Image {
+	return t.apply(img, width, height, 0, 1)
+}
+
+func (t animationTransform) ApplyFrame(img image.Image, width, height, frame, frames int) draw.Image {
+	return t.apply(img, width, height, frame, frames)
+}
+
+// Transforms that also implement Describer get their description and parameters
+// shown when listing them
+type Describer interface {
+	Description() string
+	Params() []string // Settings the transform runs with, like "amplitude=20"
+}
+
+// Wraps t with a one line description and the parameters it runs with. Animations stay animated
+func describe(t Transform, description string, params ...string) Transform {
+	described := describedTransform{t, description, params}
+	if a, ok := t.(Animated); ok {
+		return describedAnimation{described, a}
+	}
+	return described
+}
+
+type describedTransform struct {
+	Transform
+	description string
+	params      []string
+}
+
+func (t describedTransform) Description() string { return t.description }
+
+func (t describedTransform) Params() []string { return t.params }
+
+type describedAnimation struct {
+	describedTransform
+	animated Animated
+}
+
+func (t describedAnimation) ApplyFrame(img image.Image, width, height, frame, frames int) draw.Image {
+	return t.animated.ApplyFrame(img, width, height, frame, frames)
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]Transform)
+)
+
+// Makes t available under name. Registering a nil transform or a name that is
+// already taken panics, the same way it would for two init functions clashing
+func Register(name string, t Transform) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if t == nil {
+		panic("wackygif: Register transform is nil")
+	}
+	if _, dup := registry[name]; dup {
+		panic(fmt.Sprintf("wackygif: Register called twice for transform %q", name))
+	}
+	registry[name] = t
+}
+
+// Names of all the registered transforms in alphabetical order
+func ListTransforms() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Finds the transform registered under name
+func Lookup(name string) (Transform, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	t, ok := registry[name]
+	return t, ok
+}
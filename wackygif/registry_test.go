@@ -0,0 +1,79 @@
+package wackygif
+
+import (
+	"image"
+	"image/draw"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// Runs f and returns what it panicked with, nil when it did not panic
+func panicValue(f func()) (v any) {
+	defer func() { v = recover() }()
+	f()
+	return nil
+}
+
+func identity(img image.Image, width, height int) draw.Image {
+	newImg := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(newImg, newImg.Bounds(), img, image.Point{}, draw.Src)
+	return newImg
+}
+
+func TestRegisterDuplicatePanics(t *testing.T) {
+	v := panicValue(func() { Register("swap", NewTransform("swap", identity)) })
+	msg, _ := v.(string)
+	if !strings.Contains(msg, `"swap"`) {
+		t.Errorf("registering swap twice panicked with %v, want a message naming it", v)
+	}
+}
+
+func TestRegisterNilPanics(t *testing.T) {
+	if v := panicValue(func() { Register("nothing", nil) }); v == nil {
+		t.Error("registering a nil transform did not panic")
+	}
+	if _, ok := Lookup("nothing"); ok {
+		t.Error("the nil transform got registered anyway")
+	}
+}
+
+func TestRegisterAndLookup(t *testing.T) {
+	const name = "test-identity"
+	Register(name, NewTransform(name, identity))
+	defer func() {
+		registryMu.Lock()
+		delete(registry, name)
+		registryMu.Unlock()
+	}()
+
+	got, ok := Lookup(name)
+	if !ok || got.Name() != name {
+		t.Fatalf("Lookup(%q) = %v, %v", name, got, ok)
+	}
+	if _, ok := Lookup("no-such-transform"); ok {
+		t.Error("Lookup found a transform that was never registered")
+	}
+}
+
+func TestListTransforms(t *testing.T) {
+	names := ListTransforms()
+	if !sort.StringsAreSorted(names) {
+		t.Errorf("ListTransforms is not sorted: %v", names)
+	}
+
+	listed := make(map[string]bool)
+	for _, name := range names {
+		listed[name] = true
+	}
+	for _, list := range [][]Transform{builtins, extras, animations} {
+		for _, tr := range list {
+			if !listed[tr.Name()] {
+				t.Errorf("%s is not registered", tr.Name())
+			}
+			if _, ok := tr.(Describer); !ok {
+				t.Errorf("%s has no description", tr.Name())
+			}
+		}
+	}
+}
@@ -0,0 +1,2508 @@
+package wackygif
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"math/rand"
+	"sort"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+/* ---------------- The Transformation Functions ---------------- */
+
+func convertImageHorizontal(img image.Image, width, height int, one, two, three uint8) draw.Image {
+	newImg := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			col := img.At(x, y)
+			col2 := img.At(width-1-x, y)
+			r, g, _, a := col.RGBA()
+			_, _, b2, _ := col2.RGBA()
+			fillColor := color.RGBA{uint8(b2>>8) * one, uint8(g>>8) * two, uint8(r>>8) * three, uint8(a >> 8)}
+			newImg.SetRGBA(x, y, fillColor)
+		}
+	}
+	return newImg
+}
+
+func convertImageVertical(img image.Image, width, height int) draw.Image {
+	newImg := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			col := img.At(x, y)
+			opCol := img.At(x, height-y-1)
+			r, _, _, a := col.RGBA()
+			_, og, ob, _ := opCol.RGBA()
+			fillColor := color.RGBA{uint8(ob), uint8(og >> 8), uint8(r >> 8), uint8(a >> 8)}
+			newImg.SetRGBA(x, y, fillColor)
+		}
+	}
+	return newImg
+}
+
+func adjustBrightness(img image.Image, width, height int, factor float64) draw.Image {
+	newImg := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			col := img.At(x, y)
+			r, g, b, a := col.RGBA()
+			fillColor := color.RGBA{
+				uint8(clamp(int(float64(r>>8) * factor))),
+				uint8(clamp(int(float64(g>>8) * factor))),
+				uint8(clamp(int(float64(b>>8) * factor))),
+				uint8(a >> 8),
+			}
+			newImg.Set(x, y, fillColor)
+		}
+	}
+	return newImg
+}
+
+func clamp(value int) int {
+	if value < 0 {
+		return 0
+	}
+	if value > 255 {
+		return 255
+	}
+	return value
+}
+
+func waveImage(img image.Image, width, height int, amplitude, frequency float64) draw.Image {
+	newImg := image.NewRGBA(image.Rect(0, 0, width, height))
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			offset := int(amplitude * math.Sin(2*math.Pi*frequency*float64(y)/float64(height)))
+			srcX := (x + offset) % width
+			if srcX < 0 {
+				srcX += width
+			}
+			newImg.Set(x, y, img.At(srcX, y))
+		}
+	}
+
+	return newImg
+}
+
+func mergeImages(img1, img2 image.Image) draw.Image {
+	bounds1 := img1.Bounds()
+	width1 := bounds1.Dx()
+	height1 := bounds1.Dy()
+	bounds2 := img2.Bounds()
+	width2 := bounds2.Dx()
+	height2 := bounds2.Dy()
+
+	minHeight := min(height1, height2)
+	minWidth := min(width1, width2)
+
+	newImg := image.NewRGBA(image.Rect(0, 0, minWidth, minHeight))
+
+	for y := 0; y < minHeight; y++ {
+		for x := 0; x < minWidth; x++ {
+			col1 := img1.At(x, y)
+			col2 := img2.At(x, y)
+			_, g1, _, _ := col1.RGBA()
+			r2, _, b2, _ := col2.RGBA()
+
+			fillColor := color.RGBA{
+				uint8(r2 >> 8),
+				uint8(g1 >> 8),
+				uint8(b2 >> 8),
+				255,
+			}
+			newImg.Set(x, y, fillColor)
+		}
+	}
+	return newImg
+}
+
+func kaleidoscopeImage(img image.Image, width, height int) draw.Image {
+	newImg := image.NewRGBA(image.Rect(0, 0, width, height))
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if x < width/2 {
+				if y < height/2 {
+					newImg.Set(x, y, img.At(x, y))
+				} else {
+					newImg.Set(x, y, img.At(x, height-y-1))
+				}
+			} else {
+				if y < height/2 {
+					newImg.Set(x, y, img.At(width-x-1, y))
+				} else {
+					newImg.Set(x, y, img.At(width-x-1, height-y-1))
+				}
+			}
+		}
+	}
+
+	return newImg
+}
+
+func strong(img image.Image, width, height int) draw.Image {
+	newImg := image.NewRGBA(image.Rect(0, 0, width, height))
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			col := img.At(x, y)
+			r, g, b, _ := col.RGBA()
+			r8, g8, b8 := uint8(r>>8), uint8(g>>8), uint8(b>>8)
+
+			colorFill := color.RGBA{0, 0, 0, 255}
+			switch maxOfThree(r8, g8, b8) {
+			case 'r':
+				colorFill = color.RGBA{b8 / 2, g8 / 5, r8 / 2, 255}
+			case 'g':
+				colorFill = color.RGBA{0, b8, g8 / 2, 255}
+			case 'b':
+				colorFill = color.RGBA{g8 / 10, r8, b8 / 8, 255}
+			}
+			newImg.Set(x, y, colorFill)
+		}
+	}
+	return newImg
+}
+
+func maxOfThree(r, g, b uint8) rune {
+	if r >= g && r >= b {
+		return 'r'
+	} else if g >= r && g >= b {
+		return 'g'
+	} else {
+		return 'b'
+	}
+}
+
+func sickTwist(img image.Image, width, height int) draw.Image {
+	newImg := image.NewRGBA(image.Rect(0, 0, width, height))
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			col := img.At(x, y)
+			r, g, b, _ := col.RGBA()
+			if (x+y)%2 != 0 {
+				col = img.At(width-1-x, height-1-y)
+				g, _, b, _ = col.RGBA()
+			}
+
+			fillCol := color.RGBA{
+				uint8(r >> 8),
+				uint8(g >> 8),
+				uint8(b >> 8),
+				255,
+			}
+			newImg.Set(x, y, fillCol)
+		}
+	}
+	return newImg
+}
+
+// Keeps the content inside the chosen shape and leaves the rest transparent.
+// Supported shapes are "circle", "heart" and "star", anything else falls back to circle
+func shapeMask(img image.Image, width, height int, shape string) draw.Image {
+	newImg := image.NewRGBA(image.Rect(0, 0, width, height))
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			// Normalized coordinates in [-1, 1] with y pointing up
+			u := 2*(float64(x)+0.5)/float64(width) - 1
+			v := 1 - 2*(float64(y)+0.5)/float64(height)
+			if insideShape(shape, u, v) {
+				newImg.Set(x, y, img.At(x, y))
+			}
+		}
+	}
+	return newImg
+}
+
+func insideShape(shape string, u, v float64) bool {
+	switch shape {
+	case "heart":
+		// (x² + y² - 1)³ - x²y³ <= 0, scaled down a bit to fit the frame
+		hx, hy := u*1.25, v*1.25+0.15
+		a := hx*hx + hy*hy - 1
+		return a*a*a-hx*hx*hy*hy*hy <= 0
+	case "star":
+		return insidePolygon(starPolygon(5, 1, 0.4), u, v)
+	default:
+		return u*u+v*v <= 1
+	}
+}
+
+// Builds a star with the given number of points, alternating between the outer and inner radius
+func starPolygon(points int, outer, inner float64) [][2]float64 {
+	poly := make([][2]float64, 0, points*2)
+	for i := 0; i < points*2; i++ {
+		r := outer
+		if i%2 == 1 {
+			r = inner
+		}
+		angle := math.Pi/2 + float64(i)*math.Pi/float64(points)
+		poly = append(poly, [2]float64{r * math.Cos(angle), r * math.Sin(angle)})
+	}
+	return poly
+}
+
+// Even-odd ray casting test
+func insidePolygon(poly [][2]float64, x, y float64) bool {
+	inside := false
+	for i, j := 0, len(poly)-1; i < len(poly); j, i = i, i+1 {
+		xi, yi := poly[i][0], poly[i][1]
+		xj, yj := poly[j][0], poly[j][1]
+		if (yi > y) != (yj > y) && x < (xj-xi)*(y-yi)/(yj-yi)+xi {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// Removes low energy vertical seams until the image is targetWidth wide
+// and then stretches it back out to the original width
+func seamSquish(img image.Image, width, height int, targetWidth int) draw.Image {
+	targetWidth = max(1, min(targetWidth, width))
+
+	// Working copy of the pixels, one slice per row so seams can be cut out
+	rows := make([][]color.RGBA, height)
+	for y := 0; y < height; y++ {
+		rows[y] = make([]color.RGBA, width)
+		for x := 0; x < width; x++ {
+			rows[y][x] = color.RGBAModel.Convert(img.At(x, y)).(color.RGBA)
+		}
+	}
+
+	for curWidth := width; curWidth > targetWidth; curWidth-- {
+		lum := make([]float64, curWidth*height)
+		for y := 0; y < height; y++ {
+			for x := 0; x < curWidth; x++ {
+				lum[y*curWidth+x] = luma(rows[y][x])
+			}
+		}
+
+		// Cumulative minimum energy from the top row down
+		cost := make([]float64, curWidth*height)
+		for y := 0; y < height; y++ {
+			for x := 0; x < curWidth; x++ {
+				gx, gy := sobel(lum, curWidth, height, x, y)
+				energy := math.Hypot(gx, gy)
+				if y > 0 {
+					best := cost[(y-1)*curWidth+x]
+					if x > 0 {
+						best = math.Min(best, cost[(y-1)*curWidth+x-1])
+					}
+					if x < curWidth-1 {
+						best = math.Min(best, cost[(y-1)*curWidth+x+1])
+					}
+					energy += best
+				}
+				cost[y*curWidth+x] = energy
+			}
+		}
+
+		// Walk the cheapest seam back up and cut it out of every row
+		seamX := 0
+		for x := 1; x < curWidth; x++ {
+			if cost[(height-1)*curWidth+x] < cost[(height-1)*curWidth+seamX] {
+				seamX = x
+			}
+		}
+		for y := height - 1; y >= 0; y-- {
+			rows[y] = append(rows[y][:seamX], rows[y][seamX+1:]...)
+			if y == 0 {
+				break
+			}
+			prev := seamX
+			for _, x := range []int{seamX - 1, seamX + 1} {
+				if x >= 0 && x < curWidth && cost[(y-1)*curWidth+x] < cost[(y-1)*curWidth+prev] {
+					prev = x
+				}
+			}
+			seamX = prev
+		}
+	}
+
+	newImg := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			newImg.SetRGBA(x, y, rows[y][x*targetWidth/width])
+		}
+	}
+	return newImg
+}
+
+// Perceived brightness of a color in the range 0-255
+func luma(col color.Color) float64 {
+	r, g, b, _ := col.RGBA()
+	return 0.299*float64(r>>8) + 0.587*float64(g>>8) + 0.114*float64(b>>8)
+}
+
+// Sobel gradient at (x, y) of a width*height luma map, clamping samples at the borders
+func sobel(lum []float64, width, height, x, y int) (gx, gy float64) {
+	at := func(dx, dy int) float64 {
+		sx := max(0, min(x+dx, width-1))
+		sy := max(0, min(y+dy, height-1))
+		return lum[sy*width+sx]
+	}
+	gx = -at(-1, -1) - 2*at(-1, 0) - at(-1, 1) + at(1, -1) + 2*at(1, 0) + at(1, 1)
+	gy = -at(-1, -1) - 2*at(0, -1) - at(1, -1) + at(-1, 1) + 2*at(0, 1) + at(1, 1)
+	return gx, gy
+}
+
+// Renders every cell*cell block as a solid square of its average color, with
+// gap dark pixels between the squares like an LED display
+func ledWall(img image.Image, width, height int, cell int, gap int) draw.Image {
+	newImg := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(newImg, newImg.Bounds(), image.NewUniform(color.RGBA{0, 0, 0, 255}), image.Point{}, draw.Src)
+	cell = max(cell, 1)
+	gap = max(0, min(gap, cell-1))
+
+	for by := 0; by < height; by += cell {
+		for bx := 0; bx < width; bx += cell {
+			block := image.Rect(bx, by, min(bx+cell, width), min(by+cell, height))
+			lit := image.Rect(bx, by, bx+cell-gap, by+cell-gap).Intersect(block)
+			draw.Draw(newImg, lit, image.NewUniform(averageColor(img, block)), image.Point{}, draw.Src)
+		}
+	}
+	return newImg
+}
+
+// Average color of all the pixels inside rect
+func averageColor(img image.Image, rect image.Rectangle) color.RGBA {
+	var sumR, sumG, sumB, sumA, count uint64
+	for y := rect.Min.Y; y < rect.Max.Y; y++ {
+		for x := rect.Min.X; x < rect.Max.X; x++ {
+			r, g, b, a := img.At(x, y).RGBA()
+			sumR += uint64(r >> 8)
+			sumG += uint64(g >> 8)
+			sumB += uint64(b >> 8)
+			sumA += uint64(a >> 8)
+			count++
+		}
+	}
+	if count == 0 {
+		return color.RGBA{}
+	}
+	return color.RGBA{uint8(sumR / count), uint8(sumG / count), uint8(sumB / count), uint8(sumA / count)}
+}
+
+// Replaces every pixel with a random neighbour within radius, the same seed gives the same glass
+func frostedGlass(img image.Image, width, height int, radius int, seed int64) draw.Image {
+	newImg := image.NewRGBA(image.Rect(0, 0, width, height))
+	rng := rand.New(rand.NewSource(seed))
+	radius = max(radius, 0)
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			srcX := max(0, min(x+rng.Intn(2*radius+1)-radius, width-1))
+			srcY := max(0, min(y+rng.Intn(2*radius+1)-radius, height-1))
+			newImg.Set(x, y, img.At(srcX, srcY))
+		}
+	}
+	return newImg
+}
+
+// Seeds particles on a grid and traces short paths along the luma gradient,
+// drawing them in the color of the image underneath on a dark background
+func streamlines(img image.Image, width, height int, step int) draw.Image {
+	newImg := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(newImg, newImg.Bounds(), image.NewUniform(color.RGBA{0, 0, 0, 255}), image.Point{}, draw.Src)
+	step = max(step, 1)
+	lum := lumaMap(img, width, height)
+	length := step * 2
+
+	for sy := step / 2; sy < height; sy += step {
+		for sx := step / 2; sx < width; sx += step {
+			// Trace both ways from the seed so the line is centered on it
+			for _, dir := range []float64{1, -1} {
+				px, py := float64(sx), float64(sy)
+				for i := 0; i < length; i++ {
+					x, y := int(px), int(py)
+					if x < 0 || y < 0 || x >= width || y >= height {
+						break
+					}
+					gx, gy := sobel(lum, width, height, x, y)
+					mag := math.Hypot(gx, gy)
+					if mag < 1 {
+						break
+					}
+					newImg.Set(x, y, img.At(x, y))
+					px += dir * gx / mag
+					py += dir * gy / mag
+				}
+			}
+		}
+	}
+	return newImg
+}
+
+// Luma of every pixel, stored row by row
+func lumaMap(img image.Image, width, height int) []float64 {
+	lum := make([]float64, width*height)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			lum[y*width+x] = luma(img.At(x, y))
+		}
+	}
+	return lum
+}
+
+// Samples the image at a fractional position, coordinates outside the image are clamped to the edge
+func bilinear(img image.Image, width, height int, fx, fy float64) color.RGBA {
+	fx = math.Max(0, math.Min(fx, float64(width-1)))
+	fy = math.Max(0, math.Min(fy, float64(height-1)))
+	x0, y0 := int(fx), int(fy)
+	x1, y1 := min(x0+1, width-1), min(y0+1, height-1)
+	tx, ty := fx-float64(x0), fy-float64(y0)
+
+	var out [4]float64
+	for _, s := range []struct {
+		x, y int
+		w    float64
+	}{
+		{x0, y0, (1 - tx) * (1 - ty)},
+		{x1, y0, tx * (1 - ty)},
+		{x0, y1, (1 - tx) * ty},
+		{x1, y1, tx * ty},
+	} {
+		r, g, b, a := img.At(s.x, s.y).RGBA()
+		out[0] += float64(r>>8) * s.w
+		out[1] += float64(g>>8) * s.w
+		out[2] += float64(b>>8) * s.w
+		out[3] += float64(a>>8) * s.w
+	}
+	return color.RGBA{uint8(out[0] + 0.5), uint8(out[1] + 0.5), uint8(out[2] + 0.5), uint8(out[3] + 0.5)}
+}
+
+// Thresholds the left half into an ink blot and folds it over onto the right half
+func rorschach(img image.Image, width, height int, threshold uint8) draw.Image {
+	newImg := image.NewRGBA(image.Rect(0, 0, width, height))
+	ink := color.RGBA{20, 20, 25, 255}
+	paper := color.RGBA{240, 235, 225, 255}
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < (width+1)/2; x++ {
+			fillColor := paper
+			if luma(img.At(x, y)) < float64(threshold) {
+				fillColor = ink
+			}
+			newImg.SetRGBA(x, y, fillColor)
+			newImg.SetRGBA(width-x-1, y, fillColor)
+		}
+	}
+	return newImg
+}
+
+// Sorts the pixels inside every cell*cell tile by hue, filling the tile in reading order
+func hueSortTiles(img image.Image, width, height int, cell int) draw.Image {
+	newImg := image.NewRGBA(image.Rect(0, 0, width, height))
+	cell = max(cell, 1)
+
+	for by := 0; by < height; by += cell {
+		for bx := 0; bx < width; bx += cell {
+			tile := image.Rect(bx, by, min(bx+cell, width), min(by+cell, height))
+
+			var pixels []color.RGBA
+			for y := tile.Min.Y; y < tile.Max.Y; y++ {
+				for x := tile.Min.X; x < tile.Max.X; x++ {
+					pixels = append(pixels, color.RGBAModel.Convert(img.At(x, y)).(color.RGBA))
+				}
+			}
+			sort.SliceStable(pixels, func(i, j int) bool {
+				hi, _, _ := rgbToHSV(pixels[i])
+				hj, _, _ := rgbToHSV(pixels[j])
+				return hi < hj
+			})
+
+			i := 0
+			for y := tile.Min.Y; y < tile.Max.Y; y++ {
+				for x := tile.Min.X; x < tile.Max.X; x++ {
+					newImg.SetRGBA(x, y, pixels[i])
+					i++
+				}
+			}
+		}
+	}
+	return newImg
+}
+
+// Returns hue in degrees, saturation and value in 0-1
+func rgbToHSV(col color.Color) (h, s, v float64) {
+	r, g, b, _ := col.RGBA()
+	rf, gf, bf := float64(r>>8)/255, float64(g>>8)/255, float64(b>>8)/255
+	maxC := math.Max(rf, math.Max(gf, bf))
+	minC := math.Min(rf, math.Min(gf, bf))
+	delta := maxC - minC
+
+	switch {
+	case delta == 0:
+		h = 0
+	case maxC == rf:
+		h = 60 * math.Mod((gf-bf)/delta, 6)
+	case maxC == gf:
+		h = 60 * ((bf-rf)/delta + 2)
+	default:
+		h = 60 * ((rf-gf)/delta + 4)
+	}
+	if h < 0 {
+		h += 360
+	}
+	if maxC > 0 {
+		s = delta / maxC
+	}
+	return h, s, maxC
+}
+
+// Floyd-Steinberg dithers the image to the given palette
+func ditherToPalette(img image.Image, width, height int, pal color.Palette) draw.Image {
+	newImg := image.NewPaletted(image.Rect(0, 0, width, height), pal)
+	draw.FloydSteinberg.Draw(newImg, newImg.Bounds(), img, img.Bounds().Min)
+	return newImg
+}
+
+// Keeps splitting regions into quarters while their color variance is above
+// threshold and fills every leaf with its average color
+func quadTree(img image.Image, width, height int, threshold float64) draw.Image {
+	newImg := image.NewRGBA(image.Rect(0, 0, width, height))
+
+	var split func(rect image.Rectangle)
+	split = func(rect image.Rectangle) {
+		if rect.Empty() {
+			return
+		}
+		if (rect.Dx() > 1 || rect.Dy() > 1) && colorVariance(img, rect) > threshold {
+			midX := rect.Min.X + rect.Dx()/2
+			midY := rect.Min.Y + rect.Dy()/2
+			split(image.Rect(rect.Min.X, rect.Min.Y, midX, midY))
+			split(image.Rect(midX, rect.Min.Y, rect.Max.X, midY))
+			split(image.Rect(rect.Min.X, midY, midX, rect.Max.Y))
+			split(image.Rect(midX, midY, rect.Max.X, rect.Max.Y))
+			return
+		}
+		draw.Draw(newImg, rect, image.NewUniform(averageColor(img, rect)), image.Point{}, draw.Src)
+	}
+	split(image.Rect(0, 0, width, height))
+
+	return newImg
+}
+
+// Variance of the pixels inside rect, averaged over the red, green and blue channels
+func colorVariance(img image.Image, rect image.Rectangle) float64 {
+	var sum, sumSq [3]float64
+	count := float64(rect.Dx() * rect.Dy())
+	if count <= 0 {
+		return 0
+	}
+	for y := rect.Min.Y; y < rect.Max.Y; y++ {
+		for x := rect.Min.X; x < rect.Max.X; x++ {
+			r, g, b, _ := img.At(x, y).RGBA()
+			for i, c := range []float64{float64(r >> 8), float64(g >> 8), float64(b >> 8)} {
+				sum[i] += c
+				sumSq[i] += c * c
+			}
+		}
+	}
+
+	variance := 0.0
+	for i := range sum {
+		mean := sum[i] / count
+		variance += sumSq[i]/count - mean*mean
+	}
+	return variance / 3
+}
+
+// Smears the bright pixels along arcs around the center, like a long exposure of the night sky.
+// Everything below the brightness threshold stays sharp
+func starTrails(img image.Image, width, height int, angle float64, samples int) draw.Image {
+	const threshold = 180
+
+	newImg := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(newImg, newImg.Bounds(), img, image.Point{}, draw.Src)
+	cx, cy := float64(width-1)/2, float64(height-1)/2
+	samples = max(samples, 1)
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			col := img.At(x, y)
+			if luma(col) < threshold {
+				continue
+			}
+			r, g, b, _ := col.RGBA()
+			for i := 1; i <= samples; i++ {
+				t := float64(i) / float64(samples)
+				px, py := rotatePoint(float64(x), float64(y), cx, cy, angle*t)
+				dx, dy := int(math.Round(px)), int(math.Round(py))
+				if dx < 0 || dy < 0 || dx >= width || dy >= height {
+					continue
+				}
+
+				// The trail fades out the further it gets from the star, lighten keeps the brightest
+				fade := 1 - t*0.75
+				cur := newImg.RGBAAt(dx, dy)
+				newImg.SetRGBA(dx, dy, color.RGBA{
+					max(cur.R, uint8(float64(r>>8)*fade)),
+					max(cur.G, uint8(float64(g>>8)*fade)),
+					max(cur.B, uint8(float64(b>>8)*fade)),
+					cur.A,
+				})
+			}
+		}
+	}
+	return newImg
+}
+
+// Rotates (x, y) around (cx, cy) by degrees, positive angles turn clockwise on screen
+func rotatePoint(x, y, cx, cy, degrees float64) (float64, float64) {
+	sin, cos := math.Sincos(degrees * math.Pi / 180)
+	dx, dy := x-cx, y-cy
+	return cx + dx*cos - dy*sin, cy + dx*sin + dy*cos
+}
+
+// Only lets the image show through the letters of text, scaled up to fill the frame
+func textMask(img image.Image, width, height int, text string) draw.Image {
+	newImg := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(newImg, newImg.Bounds(), image.NewUniform(color.RGBA{0, 0, 0, 255}), image.Point{}, draw.Src)
+
+	glyphs := textBitmap(text)
+	gw, gh := glyphs.Bounds().Dx(), glyphs.Bounds().Dy()
+	if gw == 0 || gh == 0 {
+		return newImg
+	}
+
+	// Largest scale that fits the text in 90% of the frame, centered
+	scale := math.Min(float64(width)*0.9/float64(gw), float64(height)*0.9/float64(gh))
+	offX := (float64(width) - float64(gw)*scale) / 2
+	offY := (float64(height) - float64(gh)*scale) / 2
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			gx := int(math.Floor((float64(x) - offX) / scale))
+			gy := int(math.Floor((float64(y) - offY) / scale))
+			if gx < 0 || gy < 0 || gx >= gw || gy >= gh {
+				continue
+			}
+			if glyphs.AlphaAt(gx, gy).A > 127 {
+				newImg.Set(x, y, img.At(x, y))
+			}
+		}
+	}
+	return newImg
+}
+
+// Renders text with the built in 7x13 bitmap font into a tightly sized alpha mask
+func textBitmap(text string) *image.Alpha {
+	face := basicfont.Face7x13
+	drawer := &font.Drawer{Face: face}
+	bitmap := image.NewAlpha(image.Rect(0, 0, drawer.MeasureString(text).Ceil(), face.Height))
+
+	drawer.Dst = bitmap
+	drawer.Src = image.Opaque
+	drawer.Dot = fixed.P(0, face.Ascent)
+	drawer.DrawString(text)
+	return bitmap
+}
+
+// Applies the same gamma curve to all three channels
+func adjustGamma(img image.Image, width, height int, gamma float64) draw.Image {
+	return channelGamma(img, width, height, gamma, gamma, gamma)
+}
+
+// Applies a separate gamma curve to each channel for some creative color grading
+func channelGamma(img image.Image, width, height int, gR, gG, gB float64) draw.Image {
+	newImg := image.NewRGBA(image.Rect(0, 0, width, height))
+	lutR, lutG, lutB := gammaLUT(gR), gammaLUT(gG), gammaLUT(gB)
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			r, g, b, a := img.At(x, y).RGBA()
+			newImg.SetRGBA(x, y, color.RGBA{lutR[r>>8], lutG[g>>8], lutB[b>>8], uint8(a >> 8)})
+		}
+	}
+	return newImg
+}
+
+// Lookup table for out = 255 * (in/255)^(1/gamma), gamma above 1 brightens the midtones
+func gammaLUT(gamma float64) [256]uint8 {
+	var lut [256]uint8
+	if gamma <= 0 {
+		gamma = 1
+	}
+	for i := range lut {
+		lut[i] = uint8(clamp(int(math.Round(255 * math.Pow(float64(i)/255, 1/gamma)))))
+	}
+	return lut
+}
+
+// Warps the image with a grid*grid lattice of control points. displacements holds the (dx, dy)
+// of every control point row by row, when it is nil the points are moved randomly from seed
+func meshWarp(img image.Image, width, height int, grid int, displacements [][2]float64, seed int64) draw.Image {
+	newImg := image.NewRGBA(image.Rect(0, 0, width, height))
+	grid = max(grid, 2)
+	cellW := float64(width-1) / float64(grid-1)
+	cellH := float64(height-1) / float64(grid-1)
+
+	if displacements == nil {
+		rng := rand.New(rand.NewSource(seed))
+		displacements = make([][2]float64, grid*grid)
+		for i := range displacements {
+			displacements[i] = [2]float64{(rng.Float64() - 0.5) * cellW / 2, (rng.Float64() - 0.5) * cellH / 2}
+		}
+	}
+	offset := func(i, j int) [2]float64 {
+		if k := j*grid + i; k < len(displacements) {
+			return displacements[k]
+		}
+		return [2]float64{}
+	}
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			// Cell of the lattice the pixel is in and where inside it
+			fx, fy := float64(x)/math.Max(cellW, 1), float64(y)/math.Max(cellH, 1)
+			i, j := min(int(fx), grid-2), min(int(fy), grid-2)
+			tx, ty := fx-float64(i), fy-float64(j)
+
+			d00, d10 := offset(i, j), offset(i+1, j)
+			d01, d11 := offset(i, j+1), offset(i+1, j+1)
+			dx := (d00[0]*(1-tx)+d10[0]*tx)*(1-ty) + (d01[0]*(1-tx)+d11[0]*tx)*ty
+			dy := (d00[1]*(1-tx)+d10[1]*tx)*(1-ty) + (d01[1]*(1-tx)+d11[1]*tx)*ty
+
+			newImg.SetRGBA(x, y, bilinear(img, width, height, float64(x)-dx, float64(y)-dy))
+		}
+	}
+	return newImg
+}
+
+// Lets the color of saturated pixels leak into the neighbours within radius, like old analog video
+func colorBleed(img image.Image, width, height int, radius int, strength float64) draw.Image {
+	const minSaturation = 0.5
+
+	newImg := image.NewRGBA(image.Rect(0, 0, width, height))
+	radius = max(radius, 0)
+
+	// The chroma (color minus its gray value) of every saturated pixel
+	chroma := make([][3]float64, width*height)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			col := img.At(x, y)
+			if _, s, _ := rgbToHSV(col); s < minSaturation {
+				continue
+			}
+			r, g, b, _ := col.RGBA()
+			l := luma(col)
+			chroma[y*width+x] = [3]float64{float64(r>>8) - l, float64(g>>8) - l, float64(b>>8) - l}
+		}
+	}
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			var bleed [3]float64
+			for dy := -radius; dy <= radius; dy++ {
+				for dx := -radius; dx <= radius; dx++ {
+					sx, sy := x+dx, y+dy
+					if (dx == 0 && dy == 0) || sx < 0 || sy < 0 || sx >= width || sy >= height {
+						continue
+					}
+					w := 1 - math.Hypot(float64(dx), float64(dy))/float64(radius+1)
+					if w <= 0 {
+						continue
+					}
+					c := chroma[sy*width+sx]
+					for i := range bleed {
+						bleed[i] += c[i] * w * strength
+					}
+				}
+			}
+
+			r, g, b, a := img.At(x, y).RGBA()
+			fillColor := color.RGBA{
+				uint8(clamp(int(math.Round(float64(r>>8) + bleed[0])))),
+				uint8(clamp(int(math.Round(float64(g>>8) + bleed[1])))),
+				uint8(clamp(int(math.Round(float64(b>>8) + bleed[2])))),
+				uint8(a >> 8),
+			}
+			newImg.SetRGBA(x, y, fillColor)
+		}
+	}
+	return newImg
+}
+
+// Stacks count copies of the image on top of each other, every copy smaller,
+// more rotated and slightly see-through. The first copy fills the whole frame
+func nestedSquares(img image.Image, width, height int, count int) draw.Image {
+	const (
+		shrink = 0.8
+		turn   = 15.0
+		alpha  = 0.8
+	)
+
+	newImg := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(newImg, newImg.Bounds(), img, image.Point{}, draw.Src)
+	cx, cy := float64(width-1)/2, float64(height-1)/2
+
+	for k := 1; k < count; k++ {
+		scale := math.Pow(shrink, float64(k))
+		for y := 0; y < height; y++ {
+			for x := 0; x < width; x++ {
+				// Undo the rotation and scaling to find where the copy samples from
+				rx, ry := rotatePoint(float64(x), float64(y), cx, cy, -turn*float64(k))
+				srcX, srcY := cx+(rx-cx)/scale, cy+(ry-cy)/scale
+				if srcX < 0 || srcY < 0 || srcX > float64(width-1) || srcY > float64(height-1) {
+					continue
+				}
+
+				top := bilinear(img, width, height, srcX, srcY)
+				cur := newImg.RGBAAt(x, y)
+				newImg.SetRGBA(x, y, color.RGBA{
+					uint8(float64(cur.R)*(1-alpha) + float64(top.R)*alpha),
+					uint8(float64(cur.G)*(1-alpha) + float64(top.G)*alpha),
+					uint8(float64(cur.B)*(1-alpha) + float64(top.B)*alpha),
+					cur.A,
+				})
+			}
+		}
+	}
+	return newImg
+}
+
+// Separates the image into cyan, magenta, yellow and black and prints each of
+// them as a dot screen at the traditional angles, like a magazine under a loupe
+func cmykHalftone(img image.Image, width, height int, cellSize int) draw.Image {
+	newImg := image.NewRGBA(image.Rect(0, 0, width, height))
+	cellSize = max(cellSize, 2)
+	cell := float64(cellSize)
+	angles := [4]float64{15, 75, 0, 45} // C, M, Y, K
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			var inked [4]bool
+			for ch, angle := range angles {
+				// Find the center of the screen cell this pixel falls in
+				sx, sy := rotatePoint(float64(x), float64(y), 0, 0, -angle)
+				cx := (math.Floor(sx/cell) + 0.5) * cell
+				cy := (math.Floor(sy/cell) + 0.5) * cell
+				ix, iy := rotatePoint(cx, cy, 0, 0, angle)
+				srcX := max(0, min(int(ix), width-1))
+				srcY := max(0, min(int(iy), height-1))
+
+				// Dot area grows with the amount of ink
+				ink := cmyk(img.At(srcX, srcY))[ch]
+				radius := math.Sqrt(ink) * cell / math.Sqrt2
+				inked[ch] = ink > 0 && math.Hypot(sx-cx, sy-cy) <= radius
+			}
+
+			r, g, b := 255.0, 255.0, 255.0
+			if inked[0] {
+				r = 0
+			}
+			if inked[1] {
+				g = 0
+			}
+			if inked[2] {
+				b = 0
+			}
+			if inked[3] {
+				r, g, b = 0, 0, 0
+			}
+			newImg.SetRGBA(x, y, color.RGBA{uint8(r), uint8(g), uint8(b), 255})
+		}
+	}
+	return newImg
+}
+
+// Naive RGB to CMYK conversion, every component is in 0-1
+func cmyk(col color.Color) [4]float64 {
+	r, g, b, _ := col.RGBA()
+	rf, gf, bf := float64(r>>8)/255, float64(g>>8)/255, float64(b>>8)/255
+	k := 1 - math.Max(rf, math.Max(gf, bf))
+	if k >= 1 {
+		return [4]float64{0, 0, 0, 1}
+	}
+	return [4]float64{(1 - rf - k) / (1 - k), (1 - gf - k) / (1 - k), (1 - bf - k) / (1 - k), k}
+}
+
+// Halves the image levels times by averaging 2x2 blocks and blows it back up
+// to full size, a chunky and cheap blur
+func mipBlur(img image.Image, width, height int, levels int) draw.Image {
+	current := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(current, current.Bounds(), img, image.Point{}, draw.Src)
+
+	for i := 0; i < levels && (current.Bounds().Dx() > 1 || current.Bounds().Dy() > 1); i++ {
+		cw, ch := current.Bounds().Dx(), current.Bounds().Dy()
+		half := image.NewRGBA(image.Rect(0, 0, (cw+1)/2, (ch+1)/2))
+		for y := 0; y < half.Bounds().Dy(); y++ {
+			for x := 0; x < half.Bounds().Dx(); x++ {
+				block := image.Rect(x*2, y*2, x*2+2, y*2+2).Intersect(current.Bounds())
+				half.SetRGBA(x, y, averageColor(current, block))
+			}
+		}
+		current = half
+	}
+
+	newImg := image.NewRGBA(image.Rect(0, 0, width, height))
+	cw, ch := current.Bounds().Dx(), current.Bounds().Dy()
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			newImg.SetRGBA(x, y, current.RGBAAt(x*cw/width, y*ch/height))
+		}
+	}
+	return newImg
+}
+
+// Wraps the image around a vertical cylinder so the columns get squeezed towards
+// the left and right edges. curvature goes from 0 (flat) to 1 (half the cylinder visible)
+func cylinderWrap(img image.Image, width, height int, curvature float64) draw.Image {
+	newImg := image.NewRGBA(image.Rect(0, 0, width, height))
+	curvature = math.Max(0, math.Min(curvature, 1))
+	if curvature == 0 || width < 2 {
+		draw.Draw(newImg, newImg.Bounds(), img, image.Point{}, draw.Src)
+		return newImg
+	}
+
+	cx := float64(width-1) / 2
+	maxAngle := curvature * math.Pi / 2
+	for x := 0; x < width; x++ {
+		// Angle on the cylinder seen at this column, and where that angle is on the flat image
+		u := (float64(x) - cx) / cx
+		angle := math.Asin(u * math.Sin(maxAngle))
+		srcX := cx + angle/maxAngle*cx
+		for y := 0; y < height; y++ {
+			newImg.SetRGBA(x, y, bilinear(img, width, height, srcX, float64(y)))
+		}
+	}
+	return newImg
+}
+
+// Reorders the rows from darkest to brightest by their average luma
+func rowSort(img image.Image, width, height int) draw.Image {
+	newImg := image.NewRGBA(image.Rect(0, 0, width, height))
+
+	rows := make([]int, height)
+	brightness := make([]float64, height)
+	for y := 0; y < height; y++ {
+		rows[y] = y
+		for x := 0; x < width; x++ {
+			brightness[y] += luma(img.At(x, y))
+		}
+	}
+	sort.SliceStable(rows, func(i, j int) bool { return brightness[rows[i]] < brightness[rows[j]] })
+
+	for y, srcY := range rows {
+		for x := 0; x < width; x++ {
+			newImg.Set(x, y, img.At(x, srcY))
+		}
+	}
+	return newImg
+}
+
+// Colors the edges by the direction of the gradient on a dark background, so
+// every edge orientation gets its own hue from the color wheel
+func chromaEdges(img image.Image, width, height int) draw.Image {
+	newImg := image.NewRGBA(image.Rect(0, 0, width, height))
+	lum := lumaMap(img, width, height)
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			gx, gy := sobel(lum, width, height, x, y)
+			magnitude := math.Min(math.Hypot(gx, gy), 255)
+			hue := math.Atan2(gy, gx) * 180 / math.Pi
+			r, g, b := hsvToRGB(hue, 1, magnitude/255)
+			newImg.SetRGBA(x, y, color.RGBA{uint8(r), uint8(g), uint8(b), 255})
+		}
+	}
+	return newImg
+}
+
+// Scatters points over the image, triangulates them and fills every triangle
+// with the average color underneath it. The same seed gives the same triangles
+func lowPoly(img image.Image, width, height int, points int, seed int64) draw.Image {
+	newImg := image.NewRGBA(image.Rect(0, 0, width, height))
+	rng := rand.New(rand.NewSource(seed))
+
+	// The corners make sure the triangles cover the whole frame
+	w, h := float64(width), float64(height)
+	pts := [][2]float64{{0, 0}, {w, 0}, {0, h}, {w, h}}
+	for i := 0; i < points; i++ {
+		pts = append(pts, [2]float64{rng.Float64() * w, rng.Float64() * h})
+	}
+	triangles := delaunay(pts)
+
+	// Which triangle every pixel belongs to, -1 for none
+	owner := make([]int, width*height)
+	for i := range owner {
+		owner[i] = -1
+	}
+	sums := make([][4]uint64, len(triangles))
+	for t, tri := range triangles {
+		a, b, c := pts[tri[0]], pts[tri[1]], pts[tri[2]]
+		minX := max(0, int(math.Floor(math.Min(a[0], math.Min(b[0], c[0])))))
+		maxX := min(width-1, int(math.Ceil(math.Max(a[0], math.Max(b[0], c[0])))))
+		minY := max(0, int(math.Floor(math.Min(a[1], math.Min(b[1], c[1])))))
+		maxY := min(height-1, int(math.Ceil(math.Max(a[1], math.Max(b[1], c[1])))))
+		for y := minY; y <= maxY; y++ {
+			for x := minX; x <= maxX; x++ {
+				if owner[y*width+x] != -1 || !insidePolygon([][2]float64{a, b, c}, float64(x)+0.5, float64(y)+0.5) {
+					continue
+				}
+				owner[y*width+x] = t
+				r, g, b, _ := img.At(x, y).RGBA()
+				sums[t][0] += uint64(r >> 8)
+				sums[t][1] += uint64(g >> 8)
+				sums[t][2] += uint64(b >> 8)
+				sums[t][3]++
+			}
+		}
+	}
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			t := owner[y*width+x]
+			if t == -1 || sums[t][3] == 0 {
+				newImg.Set(x, y, img.At(x, y))
+				continue
+			}
+			s := sums[t]
+			newImg.SetRGBA(x, y, color.RGBA{uint8(s[0] / s[3]), uint8(s[1] / s[3]), uint8(s[2] / s[3]), 255})
+		}
+	}
+	return newImg
+}
+
+// Bowyer-Watson Delaunay triangulation, returns the triangles as indexes into pts
+func delaunay(pts [][2]float64) [][3]int {
+	if len(pts) < 3 {
+		return nil
+	}
+
+	// Start with a super triangle that contains every point
+	minX, minY, maxX, maxY := pts[0][0], pts[0][1], pts[0][0], pts[0][1]
+	for _, p := range pts {
+		minX, minY = math.Min(minX, p[0]), math.Min(minY, p[1])
+		maxX, maxY = math.Max(maxX, p[0]), math.Max(maxY, p[1])
+	}
+	size := math.Max(maxX-minX, maxY-minY) * 20
+	midX, midY := (minX+maxX)/2, (minY+maxY)/2
+	all := append(append([][2]float64{}, pts...),
+		[2]float64{midX - size, midY - size},
+		[2]float64{midX + size, midY - size},
+		[2]float64{midX, midY + size},
+	)
+	n := len(pts)
+	triangles := [][3]int{{n, n + 1, n + 2}}
+
+	for i := 0; i < n; i++ {
+		p := all[i]
+
+		// Remove every triangle whose circumcircle holds the point and remember the hole's outline
+		var edges [][2]int
+		kept := triangles[:0]
+		for _, tri := range triangles {
+			if inCircumcircle(all[tri[0]], all[tri[1]], all[tri[2]], p) {
+				edges = append(edges, [2]int{tri[0], tri[1]}, [2]int{tri[1], tri[2]}, [2]int{tri[2], tri[0]})
+			} else {
+				kept = append(kept, tri)
+			}
+		}
+		triangles = kept
+
+		// Edges shared by two removed triangles are inside the hole, the rest get connected to the point
+		for j, e := range edges {
+			shared := false
+			for k, o := range edges {
+				if j != k && ((e[0] == o[0] && e[1] == o[1]) || (e[0] == o[1] && e[1] == o[0])) {
+					shared = true
+					break
+				}
+			}
+			if !shared {
+				triangles = append(triangles, [3]int{e[0], e[1], i})
+			}
+		}
+	}
+
+	// Drop everything still attached to the super triangle
+	var result [][3]int
+	for _, tri := range triangles {
+		if tri[0] < n && tri[1] < n && tri[2] < n {
+			result = append(result, tri)
+		}
+	}
+	return result
+}
+
+func inCircumcircle(a, b, c, p [2]float64) bool {
+	ax, ay := a[0]-p[0], a[1]-p[1]
+	bx, by := b[0]-p[0], b[1]-p[1]
+	cx, cy := c[0]-p[0], c[1]-p[1]
+	det := (ax*ax+ay*ay)*(bx*cy-cx*by) - (bx*bx+by*by)*(ax*cy-cx*ay) + (cx*cx+cy*cy)*(ax*by-bx*ay)
+
+	// The sign depends on the winding of the triangle
+	orientation := (b[0]-a[0])*(c[1]-a[1]) - (b[1]-a[1])*(c[0]-a[0])
+	if orientation < 0 {
+		return det < 0
+	}
+	return det > 0
+}
+
+// Cuts the image into three horizontal bands and slides the middle one right
+// and the bottom one left by shift pixels, wrapping around the edges
+func thirdsShift(img image.Image, width, height int, shift int) draw.Image {
+	newImg := image.NewRGBA(image.Rect(0, 0, width, height))
+	offsets := [3]int{0, shift, -shift}
+
+	for y := 0; y < height; y++ {
+		offset := offsets[min(y*3/height, 2)]
+		for x := 0; x < width; x++ {
+			srcX := ((x-offset)%width + width) % width
+			newImg.Set(x, y, img.At(srcX, y))
+		}
+	}
+	return newImg
+}
+
+// Covers the image in a grid*grid array of small round lenses that each magnify what is under them
+func dropletLens(img image.Image, width, height int, grid int, strength float64) draw.Image {
+	newImg := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(newImg, newImg.Bounds(), img, image.Point{}, draw.Src)
+	grid = max(grid, 1)
+	cellW, cellH := float64(width)/float64(grid), float64(height)/float64(grid)
+	radius := math.Min(cellW, cellH) / 2
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			// Center of the lens this pixel belongs to
+			cx := (math.Floor(float64(x)/cellW) + 0.5) * cellW
+			cy := (math.Floor(float64(y)/cellH) + 0.5) * cellH
+			dx, dy := float64(x)+0.5-cx, float64(y)+0.5-cy
+			r := math.Hypot(dx, dy) / radius
+			if r >= 1 || r == 0 {
+				continue
+			}
+
+			ratio := fisheyeRadius(r, strength) / r
+			newImg.SetRGBA(x, y, bilinear(img, width, height, cx+dx*ratio-0.5, cy+dy*ratio-0.5))
+		}
+	}
+	return newImg
+}
+
+// Maps a normalized radius (0-1) on the output to the radius to sample from.
+// Positive strength magnifies the center, negative shrinks it, 0 leaves it alone
+func fisheyeRadius(r, strength float64) float64 {
+	return math.Pow(r, 1+strength)
+}
+
+// Draws rows evenly spaced profile lines on black, each pushed upwards by the brightness
+// underneath it times scale. Lines further down hide the ones behind them
+func heightMap(img image.Image, width, height int, rows int, scale float64) draw.Image {
+	newImg := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(newImg, newImg.Bounds(), image.NewUniform(color.RGBA{0, 0, 0, 255}), image.Point{}, draw.Src)
+	lineColor := color.RGBA{255, 255, 255, 255}
+	rows = max(rows, 1)
+
+	for i := 0; i < rows; i++ {
+		baseline := (i + 1) * height / (rows + 1)
+		prevY := -1
+		for x := 0; x < width; x++ {
+			lineY := baseline - int(luma(img.At(x, baseline))/255*scale)
+			lineY = max(0, min(lineY, height-1))
+
+			// Black out whatever is behind the line, then draw it joined up with the previous column
+			for y := lineY + 1; y <= baseline && y < height; y++ {
+				newImg.SetRGBA(x, y, color.RGBA{0, 0, 0, 255})
+			}
+			if prevY == -1 {
+				prevY = lineY
+			}
+			for y := min(prevY, lineY); y <= max(prevY, lineY); y++ {
+				newImg.SetRGBA(x, y, lineColor)
+			}
+			prevY = lineY
+		}
+	}
+	return newImg
+}
+
+// Radial kaleidoscope, the wedge at the top of the image is mirrored around the center into segments slices
+func kaleidoscopeN(img image.Image, width, height int, segments int) draw.Image {
+	return kaleidoscopeWedge(img, width, height, segments, 0)
+}
+
+// Radial kaleidoscope that takes its source wedge rotated by offset degrees
+func kaleidoscopeWedge(img image.Image, width, height int, segments int, offset float64) draw.Image {
+	newImg := image.NewRGBA(image.Rect(0, 0, width, height))
+	cx, cy := float64(width-1)/2, float64(height-1)/2
+	wedge := 360 / float64(max(segments, 1))
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			dx, dy := float64(x)-cx, float64(y)-cy
+			angle := math.Atan2(dy, dx)*180/math.Pi + 90 // 0 degrees points up
+			if angle < 0 {
+				angle += 360
+			}
+
+			// Every other slice is mirrored so the seams line up
+			slice := math.Floor(angle / wedge)
+			local := angle - slice*wedge
+			if int(slice)%2 == 1 {
+				local = wedge - local
+			}
+
+			srcX, srcY := rotatePoint(cx, cy-math.Hypot(dx, dy), cx, cy, local+offset)
+			newImg.SetRGBA(x, y, bilinear(img, width, height, srcX, srcY))
+		}
+	}
+	return newImg
+}
+
+// Shifts every pixel sideways by a sine of its own brightness, so the warp follows
+// what is in the picture instead of where it is
+func brightnessWarp(img image.Image, width, height int, amplitude float64) draw.Image {
+	newImg := image.NewRGBA(image.Rect(0, 0, width, height))
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			offset := int(math.Round(amplitude * math.Sin(2*math.Pi*luma(img.At(x, y))/255)))
+			srcX := max(0, min(x+offset, width-1))
+			newImg.Set(x, y, img.At(srcX, y))
+		}
+	}
+	return newImg
+}
+
+// Looks like a copy of a copy from a worn out copier. detail scales how strongly the
+// local contrast turns into black toner and darkness adds shadows and speckles
+func photocopy(img image.Image, width, height int, detail, darkness float64) draw.Image {
+	const radius = 2
+
+	newImg := image.NewRGBA(image.Rect(0, 0, width, height))
+	lum := lumaMap(img, width, height)
+	rng := rand.New(rand.NewSource(1))
+	darkness = math.Max(0, math.Min(darkness, 1))
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			// Difference from the local average picks up edges and fine detail
+			sum, count := 0.0, 0.0
+			for dy := -radius; dy <= radius; dy++ {
+				for dx := -radius; dx <= radius; dx++ {
+					sx, sy := x+dx, y+dy
+					if sx >= 0 && sy >= 0 && sx < width && sy < height {
+						sum += lum[sy*width+sx]
+						count++
+					}
+				}
+			}
+			l := lum[y*width+x]
+			contrast := math.Abs(l-sum/count) * detail
+
+			toner := contrast > 32 || l < 64*darkness || rng.Float64() < darkness*0.02
+			fillColor := color.RGBA{250, 250, 245, 255}
+			if toner {
+				fillColor = color.RGBA{15, 15, 15, 255}
+			}
+			newImg.SetRGBA(x, y, fillColor)
+		}
+	}
+	return newImg
+}
+
+// Keeps a circle of focusRadius around the center sharp and blurs the rest with sigma,
+// fading between the two over a ring half as wide as the focus area
+func dofBlur(img image.Image, width, height int, focusRadius int, sigma float64) draw.Image {
+	newImg := image.NewRGBA(image.Rect(0, 0, width, height))
+	blurred := separableBlur(img, width, height, gaussianKernel(sigma))
+	cx, cy := float64(width-1)/2, float64(height-1)/2
+	inner := float64(max(focusRadius, 0))
+	ring := math.Max(inner/2, 1)
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			t := (math.Hypot(float64(x)-cx, float64(y)-cy) - inner) / ring
+			t = math.Max(0, math.Min(t, 1))
+			t = t * t * (3 - 2*t) // smoothstep
+
+			r, g, b, a := img.At(x, y).RGBA()
+			blur := blurred.RGBAAt(x, y)
+			newImg.SetRGBA(x, y, color.RGBA{
+				uint8(math.Round(float64(r>>8)*(1-t) + float64(blur.R)*t)),
+				uint8(math.Round(float64(g>>8)*(1-t) + float64(blur.G)*t)),
+				uint8(math.Round(float64(b>>8)*(1-t) + float64(blur.B)*t)),
+				uint8(math.Round(float64(a>>8)*(1-t) + float64(blur.A)*t)),
+			})
+		}
+	}
+	return newImg
+}
+
+// Normalized 1D Gaussian kernel reaching out three sigmas on each side
+func gaussianKernel(sigma float64) []float64 {
+	if sigma <= 0 {
+		return []float64{1}
+	}
+	radius := int(math.Ceil(sigma * 3))
+	kernel := make([]float64, radius*2+1)
+	sum := 0.0
+	for i := range kernel {
+		d := float64(i - radius)
+		kernel[i] = math.Exp(-d * d / (2 * sigma * sigma))
+		sum += kernel[i]
+	}
+	for i := range kernel {
+		kernel[i] /= sum
+	}
+	return kernel
+}
+
+// Convolves the image with kernel horizontally and then vertically. Samples outside the image are clamped to the edge
+func separableBlur(img image.Image, width, height int, kernel []float64) *image.RGBA {
+	radius := len(kernel) / 2
+	pass := func(src image.Image, dx, dy int) *image.RGBA {
+		dst := image.NewRGBA(image.Rect(0, 0, width, height))
+		for y := 0; y < height; y++ {
+			for x := 0; x < width; x++ {
+				var sum [4]float64
+				for i, w := range kernel {
+					sx := max(0, min(x+(i-radius)*dx, width-1))
+					sy := max(0, min(y+(i-radius)*dy, height-1))
+					r, g, b, a := src.At(sx, sy).RGBA()
+					sum[0] += float64(r>>8) * w
+					sum[1] += float64(g>>8) * w
+					sum[2] += float64(b>>8) * w
+					sum[3] += float64(a>>8) * w
+				}
+				dst.SetRGBA(x, y, color.RGBA{
+					uint8(clamp(int(math.Round(sum[0])))),
+					uint8(clamp(int(math.Round(sum[1])))),
+					uint8(clamp(int(math.Round(sum[2])))),
+					uint8(clamp(int(math.Round(sum[3])))),
+				})
+			}
+		}
+		return dst
+	}
+	return pass(pass(img, 1, 0), 0, 1)
+}
+
+// Draws the first shown characters of caption centered along the bottom of the image,
+// white with a dark drop shadow. The layout is always that of the full caption
+// so the characters stay in place when only part of it is shown
+func drawCaption(dst *image.RGBA, caption string, shown int) {
+	chars := []rune(caption)
+	shown = max(0, min(shown, len(chars)))
+	if shown == 0 {
+		return
+	}
+	full := textBitmap(caption)
+	width, height := dst.Bounds().Dx(), dst.Bounds().Dy()
+
+	// As big as fits in the width, but no taller than an eighth of the image
+	scale := max(1, min(width*9/10/max(full.Bounds().Dx(), 1), height/8/max(full.Bounds().Dy(), 1)))
+	x := (width - full.Bounds().Dx()*scale) / 2
+	y := height - full.Bounds().Dy()*scale - height/20
+
+	glyphs := textBitmap(string(chars[:shown]))
+	shadow := max(1, scale/2)
+	drawText(dst, glyphs, x+shadow, y+shadow, scale, color.RGBA{0, 0, 0, 255})
+	drawText(dst, glyphs, x, y, scale, color.RGBA{255, 255, 255, 255})
+}
+
+// Stamps a text bitmap onto dst with its top left corner at (x, y), every font pixel becoming scale*scale pixels
+func drawText(dst *image.RGBA, glyphs *image.Alpha, x, y, scale int, col color.RGBA) {
+	bounds := glyphs.Bounds()
+	for gy := bounds.Min.Y; gy < bounds.Max.Y; gy++ {
+		for gx := bounds.Min.X; gx < bounds.Max.X; gx++ {
+			if glyphs.AlphaAt(gx, gy).A <= 127 {
+				continue
+			}
+			rect := image.Rect(x+gx*scale, y+gy*scale, x+(gx+1)*scale, y+(gy+1)*scale)
+			draw.Draw(dst, rect.Intersect(dst.Bounds()), image.NewUniform(col), image.Point{}, draw.Src)
+		}
+	}
+}
+
+// Shifts the red channel of dark pixels by lowShift and the blue channel of bright
+// pixels by highShift, so shadows and highlights fringe in different directions
+func lumaSplit(img image.Image, width, height int, lowShift, highShift int) draw.Image {
+	const (
+		darkBelow   = 85
+		brightAbove = 170
+	)
+
+	newImg := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			r, g, b, a := img.At(x, y).RGBA()
+
+			// Pull the channel from the pixel the shift lands on when that pixel is in the band
+			if src := img.At(max(0, min(x-lowShift, width-1)), y); luma(src) < darkBelow {
+				r, _, _, _ = src.RGBA()
+			}
+			if src := img.At(max(0, min(x-highShift, width-1)), y); luma(src) > brightAbove {
+				_, _, b, _ = src.RGBA()
+			}
+			newImg.SetRGBA(x, y, color.RGBA{uint8(r >> 8), uint8(g >> 8), uint8(b >> 8), uint8(a >> 8)})
+		}
+	}
+	return newImg
+}
+
+// Magnifies the image by scale around its center, keeping the canvas size
+func zoom(img image.Image, width, height int, scale float64) draw.Image {
+	newImg := image.NewRGBA(image.Rect(0, 0, width, height))
+	if scale <= 0 {
+		scale = 1
+	}
+	cx, cy := float64(width-1)/2, float64(height-1)/2
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			srcX := cx + (float64(x)-cx)/scale
+			srcY := cy + (float64(y)-cy)/scale
+			newImg.SetRGBA(x, y, bilinear(img, width, height, srcX, srcY))
+		}
+	}
+	return newImg
+}
+
+// Pen stipple drawing, black dots on white placed by error diffusion so that darker
+// areas get more dots. density scales the amount of ink overall
+func stipple(img image.Image, width, height int, density float64) draw.Image {
+	newImg := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(newImg, newImg.Bounds(), image.NewUniform(color.RGBA{255, 255, 255, 255}), image.Point{}, draw.Src)
+
+	// Amount of ink wanted per pixel, 0 for none and 1 for a dot
+	ink := make([]float64, width*height)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			ink[y*width+x] = math.Max(0, math.Min((1-luma(img.At(x, y))/255)*density, 1))
+		}
+	}
+
+	// Floyd-Steinberg spreads the rounding error to the pixels not visited yet
+	spread := func(x, y int, e float64) {
+		if x >= 0 && x < width && y < height {
+			ink[y*width+x] += e
+		}
+	}
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			want := ink[y*width+x]
+			got := 0.0
+			if want >= 0.5 {
+				got = 1
+				newImg.SetRGBA(x, y, color.RGBA{0, 0, 0, 255})
+			}
+			e := want - got
+			spread(x+1, y, e*7/16)
+			spread(x-1, y+1, e*3/16)
+			spread(x, y+1, e*5/16)
+			spread(x+1, y+1, e*1/16)
+		}
+	}
+	return newImg
+}
+
+// Peels the bottom right corner of the image up like a page being turned. The flap
+// shows the faded back of the page and whatever it uncovered is left dark.
+// amount goes from 0 (flat) to 1 (folded halfway across the diagonal)
+func pageCurl(img image.Image, width, height int, amount float64) draw.Image {
+	newImg := image.NewRGBA(image.Rect(0, 0, width, height))
+	amount = math.Max(0, math.Min(amount, 1))
+	cornerX, cornerY := float64(width-1), float64(height-1)
+	diagonal := math.Hypot(cornerX, cornerY)
+	if diagonal == 0 {
+		draw.Draw(newImg, newImg.Bounds(), img, image.Point{}, draw.Src)
+		return newImg
+	}
+
+	// Distance of the fold from the corner, measured along the diagonal towards the top left
+	nx, ny := -cornerX/diagonal, -cornerY/diagonal
+	fold := amount * diagonal / 2
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			t := (float64(x)-cornerX)*nx + (float64(y)-cornerY)*ny
+			switch {
+			case t >= 2*fold:
+				newImg.Set(x, y, img.At(x, y))
+			case t >= fold:
+				// Mirror across the fold to find the part of the page lying on top
+				srcX := float64(x) + 2*(fold-t)*nx
+				srcY := float64(y) + 2*(fold-t)*ny
+				back := bilinear(img, width, height, srcX, srcY)
+
+				// Faded paper that gets darker towards the crease
+				shade := 0.6 + 0.4*(t-fold)/math.Max(fold, 1)
+				paper := func(c uint8) uint8 { return uint8((float64(c)*0.3 + 255*0.7) * shade) }
+				newImg.SetRGBA(x, y, color.RGBA{paper(back.R), paper(back.G), paper(back.B), 255})
+			default:
+				newImg.SetRGBA(x, y, color.RGBA{30, 30, 30, 255})
+			}
+		}
+	}
+	return newImg
+}
+
+// Fakes a liquid chrome surface by treating luma as the shape of the surface and
+// shading it with a dark, bright, dark metallic ramp
+func chrome(img image.Image, width, height int) draw.Image {
+	newImg := image.NewRGBA(image.Rect(0, 0, width, height))
+	lum := lumaMap(img, width, height)
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			// Slopes bend the reflection a bit like a real normal would
+			gx, gy := sobel(lum, width, height, x, y)
+			l := math.Max(0, math.Min(lum[y*width+x]+(gx+gy)/16, 255))
+			v := math.Sin(math.Pi * l / 255)
+
+			newImg.SetRGBA(x, y, color.RGBA{
+				uint8(15 + v*225),
+				uint8(15 + v*230),
+				uint8(20 + v*235),
+				255,
+			})
+		}
+	}
+	return newImg
+}
+
+// Posterizes the luma into levels bands and gives band n the hue n*hueStep degrees.
+// With a hueStep of 0 no color is added and it is a plain grayscale posterize
+func psychedelic(img image.Image, width, height int, levels int, hueStep float64) draw.Image {
+	newImg := image.NewRGBA(image.Rect(0, 0, width, height))
+	levels = max(levels, 2)
+	saturation := 1.0
+	if hueStep == 0 {
+		saturation = 0
+	}
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			col := img.At(x, y)
+			band := min(int(luma(col)/256*float64(levels)), levels-1)
+			value := float64(band) / float64(levels-1)
+			r, g, b := hsvToRGB(float64(band)*hueStep, saturation, value)
+
+			_, _, _, a := col.RGBA()
+			newImg.SetRGBA(x, y, color.RGBA{uint8(math.Round(r)), uint8(math.Round(g)), uint8(math.Round(b)), uint8(a >> 8)})
+		}
+	}
+	return newImg
+}
+
+// Writes text over and over along circles around the center on a black background,
+// every character colored by the pixel underneath it
+func textRings(img image.Image, width, height int, text string) draw.Image {
+	newImg := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(newImg, newImg.Bounds(), image.NewUniform(color.RGBA{0, 0, 0, 255}), image.Point{}, draw.Src)
+	chars := []rune(text)
+	if len(chars) == 0 {
+		return newImg
+	}
+
+	// Glyphs of the bitmap font are 7x13, scale them up for bigger images
+	scale := max(1, min(width, height)/200)
+	charW, charH := float64(7*scale), float64(13*scale)
+	cx, cy := float64(width-1)/2, float64(height-1)/2
+	maxRadius := math.Min(cx, cy) - charH/2
+
+	i := 0
+	for radius := charH; radius <= maxRadius; radius += charH {
+		count := int(2 * math.Pi * radius / charW)
+		for k := 0; k < count; k++ {
+			angle := 360 * float64(k) / float64(count)
+			px, py := rotatePoint(cx, cy-radius, cx, cy, angle)
+			col := color.RGBAModel.Convert(img.At(int(px), int(py))).(color.RGBA)
+			col.A = 255
+
+			// Stand the glyph up along the circle, its top facing outwards
+			glyph := textBitmap(string(chars[i%len(chars)]))
+			i++
+			gw, gh := glyph.Bounds().Dx(), glyph.Bounds().Dy()
+			for gy := 0; gy < gh*scale; gy++ {
+				for gx := 0; gx < gw*scale; gx++ {
+					if glyph.AlphaAt(gx/scale, gy/scale).A <= 127 {
+						continue
+					}
+					ox := px + float64(gx) - float64(gw*scale)/2
+					oy := py + float64(gy) - float64(gh*scale)/2
+					dx, dy := rotatePoint(ox, oy, px, py, angle)
+					ix, iy := int(math.Round(dx)), int(math.Round(dy))
+					if ix >= 0 && iy >= 0 && ix < width && iy < height {
+						newImg.SetRGBA(ix, iy, col)
+					}
+				}
+			}
+		}
+	}
+	return newImg
+}
+
+// Two color screen print on white paper. c2 prints the midtones and c1 the shadows on top
+// of it, the c2 layer is misregistered by offset pixels down and to the right
+func screenPrint(img image.Image, width, height int, c1, c2 color.RGBA, offset int) draw.Image {
+	const (
+		shadows  = 85
+		midtones = 170
+	)
+
+	newImg := image.NewRGBA(image.Rect(0, 0, width, height))
+	inLayer := func(x, y int, below float64) bool {
+		if x < 0 || y < 0 || x >= width || y >= height {
+			return false
+		}
+		return luma(img.At(x, y)) < below
+	}
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			// Inks multiply with the paper and each other where they overlap
+			r, g, b := 255.0, 255.0, 255.0
+			inks := []color.RGBA{}
+			if inLayer(x-offset, y-offset, midtones) {
+				inks = append(inks, c2)
+			}
+			if inLayer(x, y, shadows) {
+				inks = append(inks, c1)
+			}
+			for _, ink := range inks {
+				r *= float64(ink.R) / 255
+				g *= float64(ink.G) / 255
+				b *= float64(ink.B) / 255
+			}
+			newImg.SetRGBA(x, y, color.RGBA{uint8(r), uint8(g), uint8(b), 255})
+		}
+	}
+	return newImg
+}
+
+// Tints the image with rainbow rings around the center, the hue depends only on the
+// distance from the center. strength goes from 0 (no tint) to 1 (only rainbow)
+func radialRainbow(img image.Image, width, height int, strength float64) draw.Image {
+	const rings = 3
+
+	newImg := image.NewRGBA(image.Rect(0, 0, width, height))
+	strength = math.Max(0, math.Min(strength, 1))
+	cx, cy := float64(width-1)/2, float64(height-1)/2
+	maxRadius := math.Max(math.Hypot(cx, cy), 1)
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			hue := 360 * rings * math.Hypot(float64(x)-cx, float64(y)-cy) / maxRadius
+			tr, tg, tb := hsvToRGB(hue, 1, 1)
+
+			r, g, b, a := img.At(x, y).RGBA()
+			newImg.SetRGBA(x, y, color.RGBA{
+				uint8(float64(r>>8)*(1-strength) + tr*strength),
+				uint8(float64(g>>8)*(1-strength) + tg*strength),
+				uint8(float64(b>>8)*(1-strength) + tb*strength),
+				uint8(a >> 8),
+			})
+		}
+	}
+	return newImg
+}
+
+// Four way kaleidoscope built from whichever quadrant has the most color variance
+func bestQuadKaleido(img image.Image, width, height int) draw.Image {
+	newImg := image.NewRGBA(image.Rect(0, 0, width, height))
+	halfW, halfH := (width+1)/2, (height+1)/2
+
+	bestX, bestY, bestVariance := 0, 0, -1.0
+	for qy := 0; qy < 2; qy++ {
+		for qx := 0; qx < 2; qx++ {
+			quadrant := image.Rect(qx*(width-halfW), qy*(height-halfH), qx*(width-halfW)+halfW, qy*(height-halfH)+halfH)
+			if variance := colorVariance(img, quadrant); variance > bestVariance {
+				bestX, bestY, bestVariance = qx, qy, variance
+			}
+		}
+	}
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			// Distance from the nearest left/right and top/bottom edge, measured from the best quadrant's corner
+			u, v := min(x, width-1-x), min(y, height-1-y)
+			srcX, srcY := u, v
+			if bestX == 1 {
+				srcX = width - 1 - u
+			}
+			if bestY == 1 {
+				srcY = height - 1 - v
+			}
+			newImg.Set(x, y, img.At(srcX, srcY))
+		}
+	}
+	return newImg
+}
+
+// Sorts the neighbourhood of every pixel by luma and picks the color at the rank
+// percentile. 0 erodes to the darkest neighbour, 0.5 is a median and 1 dilates the brightest
+func rankFilter(img image.Image, width, height int, radius int, rank float64) draw.Image {
+	newImg := image.NewRGBA(image.Rect(0, 0, width, height))
+	radius = max(radius, 0)
+	rank = math.Max(0, math.Min(rank, 1))
+
+	type sample struct {
+		col  color.Color
+		luma float64
+	}
+	neighbours := make([]sample, 0, (2*radius+1)*(2*radius+1))
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			neighbours = neighbours[:0]
+			for sy := max(0, y-radius); sy <= min(y+radius, height-1); sy++ {
+				for sx := max(0, x-radius); sx <= min(x+radius, width-1); sx++ {
+					col := img.At(sx, sy)
+					neighbours = append(neighbours, sample{col, luma(col)})
+				}
+			}
+			sort.SliceStable(neighbours, func(i, j int) bool { return neighbours[i].luma < neighbours[j].luma })
+			newImg.Set(x, y, neighbours[int(math.Round(rank*float64(len(neighbours)-1)))].col)
+		}
+	}
+	return newImg
+}
+
+// Cuts the image into diagonal bands stripeWidth wide and mirrors every other band
+// across its own center line, giving a herringbone pattern
+func diagonalStripes(img image.Image, width, height int, stripeWidth int) draw.Image {
+	newImg := image.NewRGBA(image.Rect(0, 0, width, height))
+	stripeWidth = max(stripeWidth, 1)
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			// Pixels on the same band share x+y within the band's range
+			s := x + y
+			band := s / stripeWidth
+			srcX := x
+			if band%2 == 1 {
+				start := band * stripeWidth
+				mirrored := start + stripeWidth - 1 - (s - start)
+				srcX = max(0, min(mirrored-y, width-1))
+			}
+			newImg.Set(x, y, img.At(srcX, y))
+		}
+	}
+	return newImg
+}
+
+// Comic book panel, the image is printed as a halftone and text is put in a
+// speech bubble in the top left corner
+func comicPanel(img image.Image, width, height int, text string) draw.Image {
+	newImg := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(newImg, newImg.Bounds(), cmykHalftone(img, width, height, max(4, min(width, height)/60)), image.Point{}, draw.Src)
+	if text == "" {
+		return newImg
+	}
+
+	glyphs := textBitmap(text)
+	gw, gh := glyphs.Bounds().Dx(), glyphs.Bounds().Dy()
+	scale := max(1, min(width/2/max(gw, 1), height/8/max(gh, 1)))
+	textW, textH := float64(gw*scale), float64(gh*scale)
+
+	// Ellipse around the text with a tail pointing down towards the image
+	margin, border := float64(min(width, height))/30, float64(max(1, scale))
+	rx, ry := textW*0.75+margin, textH*1.1+margin/2
+	cx, cy := margin+rx, margin+ry
+	tail := [][2]float64{{cx + rx*0.2, cy + ry*0.6}, {cx + rx*0.6, cy + ry*0.4}, {cx + rx*0.9, cy + ry*1.8}}
+
+	black, white := color.RGBA{0, 0, 0, 255}, color.RGBA{255, 255, 255, 255}
+	inBubble := func(x, y, grow float64) bool {
+		ex, ey := (x-cx)/(rx+grow), (y-cy)/(ry+grow)
+		return ex*ex+ey*ey <= 1 || insidePolygon(tail, x, y)
+	}
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			px, py := float64(x)+0.5, float64(y)+0.5
+			if inBubble(px, py, 0) {
+				newImg.SetRGBA(x, y, white)
+			} else if inBubble(px, py, border) {
+				newImg.SetRGBA(x, y, black)
+			}
+		}
+	}
+
+	drawText(newImg, glyphs, int(cx-textW/2), int(cy-textH/2), scale, black)
+	return newImg
+}
+
+// Looks at the image through a wall of glass bricks. Every block*block tile shows its
+// content shifted by its own offset of up to refract*block/2 pixels, with a
+// highlight along the top left edges and a shadow along the bottom right
+func glassBlocks(img image.Image, width, height int, block int, refract float64) draw.Image {
+	newImg := image.NewRGBA(image.Rect(0, 0, width, height))
+	block = max(block, 2)
+	tilesX := (width + block - 1) / block
+	tilesY := (height + block - 1) / block
+
+	// Fixed seed so the bricks are the same every time
+	rng := rand.New(rand.NewSource(1))
+	offsets := make([][2]int, tilesX*tilesY)
+	for i := range offsets {
+		offsets[i] = [2]int{
+			int(math.Round((rng.Float64()*2 - 1) * refract * float64(block) / 2)),
+			int(math.Round((rng.Float64()*2 - 1) * refract * float64(block) / 2)),
+		}
+	}
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			offset := offsets[(y/block)*tilesX+x/block]
+			srcX := max(0, min(x+offset[0], width-1))
+			srcY := max(0, min(y+offset[1], height-1))
+			r, g, b, a := img.At(srcX, srcY).RGBA()
+
+			light := 0
+			switch {
+			case x%block == 0 || y%block == 0:
+				light = 60
+			case x%block == block-1 || y%block == block-1:
+				light = -60
+			}
+			newImg.SetRGBA(x, y, color.RGBA{
+				uint8(clamp(int(r>>8) + light)),
+				uint8(clamp(int(g>>8) + light)),
+				uint8(clamp(int(b>>8) + light)),
+				uint8(a >> 8),
+			})
+		}
+	}
+	return newImg
+}
+
+// Blurry eyed double vision, a ghost copy of the image shifted by (dx, dy) is blended on top with alpha
+func doubleVision(img image.Image, width, height int, dx, dy int, alpha float64) draw.Image {
+	newImg := image.NewRGBA(image.Rect(0, 0, width, height))
+	alpha = math.Max(0, math.Min(alpha, 1))
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			r, g, b, a := img.At(x, y).RGBA()
+			srcX, srcY := x-dx, y-dy
+			if srcX < 0 || srcY < 0 || srcX >= width || srcY >= height {
+				newImg.SetRGBA(x, y, color.RGBA{uint8(r >> 8), uint8(g >> 8), uint8(b >> 8), uint8(a >> 8)})
+				continue
+			}
+
+			gr, gg, gb, _ := img.At(srcX, srcY).RGBA()
+			newImg.SetRGBA(x, y, color.RGBA{
+				uint8(math.Round(float64(r>>8)*(1-alpha) + float64(gr>>8)*alpha)),
+				uint8(math.Round(float64(g>>8)*(1-alpha) + float64(gg>>8)*alpha)),
+				uint8(math.Round(float64(b>>8)*(1-alpha) + float64(gb>>8)*alpha)),
+				uint8(a >> 8),
+			})
+		}
+	}
+	return newImg
+}
+
+// ASCII art on black. Every cellSize*cellSize cell becomes a character from ramp, picked by
+// the cell's brightness with the last character used for the brightest cells, and drawn
+// in the cell's average color
+func asciiColor(img image.Image, width, height int, cellSize int, ramp string) draw.Image {
+	newImg := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(newImg, newImg.Bounds(), image.NewUniform(color.RGBA{0, 0, 0, 255}), image.Point{}, draw.Src)
+	chars := []rune(ramp)
+	if len(chars) == 0 {
+		return newImg
+	}
+	cellSize = max(cellSize, 1)
+
+	glyphs := make([]*image.Alpha, len(chars))
+	for i, c := range chars {
+		glyphs[i] = textBitmap(string(c))
+	}
+
+	for by := 0; by < height; by += cellSize {
+		for bx := 0; bx < width; bx += cellSize {
+			cell := image.Rect(bx, by, min(bx+cellSize, width), min(by+cellSize, height))
+			avg := averageColor(img, cell)
+			avg.A = 255
+			glyph := glyphs[min(int(luma(avg)/256*float64(len(chars))), len(chars)-1)]
+			gw, gh := glyph.Bounds().Dx(), glyph.Bounds().Dy()
+
+			// Stretch the glyph over the whole cell
+			for y := cell.Min.Y; y < cell.Max.Y; y++ {
+				for x := cell.Min.X; x < cell.Max.X; x++ {
+					gx, gy := (x-bx)*gw/cellSize, (y-by)*gh/cellSize
+					if glyph.AlphaAt(gx, gy).A > 127 {
+						newImg.SetRGBA(x, y, avg)
+					}
+				}
+			}
+		}
+	}
+	return newImg
+}
+
+// Splits the light like a prism, red stays in place while green is shifted spread/2
+// and blue spread pixels down and to the right along the diagonal
+func prism(img image.Image, width, height int, spread int) draw.Image {
+	newImg := image.NewRGBA(image.Rect(0, 0, width, height))
+	sample := func(x, y, offset int) color.Color {
+		return img.At(max(0, min(x-offset, width-1)), max(0, min(y-offset, height-1)))
+	}
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			r, _, _, a := sample(x, y, 0).RGBA()
+			_, g, _, _ := sample(x, y, spread/2).RGBA()
+			_, _, b, _ := sample(x, y, spread).RGBA()
+			newImg.SetRGBA(x, y, color.RGBA{uint8(r >> 8), uint8(g >> 8), uint8(b >> 8), uint8(a >> 8)})
+		}
+	}
+	return newImg
+}
+
+// Mandala with petals-fold symmetry. A narrow wedge from close to the center is
+// repeated around it, every other copy mirrored so each petal is symmetric too
+func mandala(img image.Image, width, height int, petals int) draw.Image {
+	petals = max(petals, 1)
+	return kaleidoscopeN(zoom(img, width, height, 2), width, height, petals*2)
+}
+
+// Plotter style line drawing, thin bright lines on black tracing both the luma
+// contours at levels steps and the strong edges
+func vectorWire(img image.Image, width, height int, levels int) draw.Image {
+	const edgeThreshold = 128
+
+	newImg := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(newImg, newImg.Bounds(), image.NewUniform(color.RGBA{0, 0, 0, 255}), image.Point{}, draw.Src)
+	lum := lumaMap(img, width, height)
+	contours := contourMap(lum, width, height, levels)
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			gx, gy := sobel(lum, width, height, x, y)
+			if contours[y*width+x] || math.Hypot(gx, gy) > edgeThreshold {
+				newImg.SetRGBA(x, y, color.RGBA{230, 255, 240, 255})
+			}
+		}
+	}
+	return newImg
+}
+
+// Marks the pixels where the luma crosses into another of levels equal bands,
+// checking against the neighbour to the right and below
+func contourMap(lum []float64, width, height int, levels int) []bool {
+	levels = max(levels, 1)
+	band := func(x, y int) int { return min(int(lum[y*width+x]/256*float64(levels)), levels-1) }
+
+	contours := make([]bool, width*height)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			b := band(x, y)
+			contours[y*width+x] = (x+1 < width && band(x+1, y) != b) || (y+1 < height && band(x, y+1) != b)
+		}
+	}
+	return contours
+}
+
+// Retro computer look, every channel is either fully on or off giving the eight colors
+// black, white, the primaries and the secondaries, with a 4x4 Bayer ordered dither
+func order8(img image.Image, width, height int) draw.Image {
+	bayer := [4][4]float64{
+		{0, 8, 2, 10},
+		{12, 4, 14, 6},
+		{3, 11, 1, 9},
+		{15, 7, 13, 5},
+	}
+
+	newImg := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			threshold := (bayer[y%4][x%4] + 0.5) / 16 * 255
+			r, g, b, _ := img.At(x, y).RGBA()
+			level := func(c uint32) uint8 {
+				if float64(c>>8) > threshold {
+					return 255
+				}
+				return 0
+			}
+			newImg.SetRGBA(x, y, color.RGBA{level(r), level(g), level(b), 255})
+		}
+	}
+	return newImg
+}
+
+// Folds the image along its main diagonal, blending every pixel 50/50 with its mirror
+// image across the diagonal. For non square images only the square that both share
+// is folded and the rest is left as it is
+func diagonalFold(img image.Image, width, height int) draw.Image {
+	newImg := image.NewRGBA(image.Rect(0, 0, width, height))
+	size := min(width, height)
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if x >= size || y >= size {
+				newImg.Set(x, y, img.At(x, y))
+				continue
+			}
+			r1, g1, b1, a1 := img.At(x, y).RGBA()
+			r2, g2, b2, a2 := img.At(y, x).RGBA()
+			newImg.SetRGBA(x, y, color.RGBA{
+				uint8((r1>>8 + r2>>8) / 2),
+				uint8((g1>>8 + g2>>8) / 2),
+				uint8((b1>>8 + b2>>8) / 2),
+				uint8((a1>>8 + a2>>8) / 2),
+			})
+		}
+	}
+	return newImg
+}
+
+// Stained glass window, the image is split into cells Voronoi regions around random
+// points from seed. Every region is filled with its average color and outlined in dark lead
+func stainedGlass(img image.Image, width, height int, cells int, seed int64) draw.Image {
+	newImg := image.NewRGBA(image.Rect(0, 0, width, height))
+	regions, count := voronoiRegions(width, height, cells, seed)
+
+	sums := make([][4]uint64, count)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			r, g, b, _ := img.At(x, y).RGBA()
+			s := &sums[regions[y*width+x]]
+			s[0] += uint64(r >> 8)
+			s[1] += uint64(g >> 8)
+			s[2] += uint64(b >> 8)
+			s[3]++
+		}
+	}
+
+	lead := color.RGBA{25, 20, 15, 255}
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			region := regions[y*width+x]
+			border := false
+			for _, d := range [][2]int{{1, 0}, {-1, 0}, {0, 1}, {0, -1}} {
+				nx, ny := x+d[0], y+d[1]
+				if nx >= 0 && ny >= 0 && nx < width && ny < height && regions[ny*width+nx] != region {
+					border = true
+				}
+			}
+			if border {
+				newImg.SetRGBA(x, y, lead)
+				continue
+			}
+			s := sums[region]
+			newImg.SetRGBA(x, y, color.RGBA{uint8(s[0] / s[3]), uint8(s[1] / s[3]), uint8(s[2] / s[3]), 255})
+		}
+	}
+	return newImg
+}
+
+// Splits the image into Voronoi regions around count random points from seed.
+// Returns the region of every pixel row by row and the number of regions
+func voronoiRegions(width, height int, count int, seed int64) ([]int, int) {
+	count = max(count, 1)
+	rng := rand.New(rand.NewSource(seed))
+	points := make([][2]float64, count)
+	for i := range points {
+		points[i] = [2]float64{rng.Float64() * float64(width), rng.Float64() * float64(height)}
+	}
+
+	regions := make([]int, width*height)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			best, bestDist := 0, math.Inf(1)
+			for i, p := range points {
+				dx, dy := float64(x)+0.5-p[0], float64(y)+0.5-p[1]
+				if dist := dx*dx + dy*dy; dist < bestDist {
+					best, bestDist = i, dist
+				}
+			}
+			regions[y*width+x] = best
+		}
+	}
+	return regions, count
+}
+
+// Black and white, pixels with a luma at or above cutoff become white and the rest black
+func threshold(img image.Image, width, height int, cutoff uint8) draw.Image {
+	newImg := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			fillColor := color.RGBA{0, 0, 0, 255}
+			if luma(img.At(x, y)) >= float64(cutoff) {
+				fillColor = color.RGBA{255, 255, 255, 255}
+			}
+			newImg.SetRGBA(x, y, fillColor)
+		}
+	}
+	return newImg
+}
+
+// Light rays shooting out of the brightest pixel, a zoom blur centered on the highlight
+func radialTrail(img image.Image, width, height int, samples int) draw.Image {
+	brightX, brightY, brightest := 0, 0, -1.0
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if l := luma(img.At(x, y)); l > brightest {
+				brightX, brightY, brightest = x, y, l
+			}
+		}
+	}
+	return zoomBlur(img, width, height, float64(brightX), float64(brightY), 0.3, samples)
+}
+
+// Blurs every pixel along the line towards (cx, cy), averaging samples points over
+// the given fraction of the distance to the center
+func zoomBlur(img image.Image, width, height int, cx, cy, strength float64, samples int) draw.Image {
+	newImg := image.NewRGBA(image.Rect(0, 0, width, height))
+	samples = max(samples, 1)
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			var sum [4]float64
+			for i := 0; i < samples; i++ {
+				t := strength * float64(i) / float64(samples)
+				col := bilinear(img, width, height, float64(x)+(cx-float64(x))*t, float64(y)+(cy-float64(y))*t)
+				sum[0] += float64(col.R)
+				sum[1] += float64(col.G)
+				sum[2] += float64(col.B)
+				sum[3] += float64(col.A)
+			}
+			n := float64(samples)
+			newImg.SetRGBA(x, y, color.RGBA{uint8(sum[0] / n), uint8(sum[1] / n), uint8(sum[2] / n), uint8(sum[3] / n)})
+		}
+	}
+	return newImg
+}
+
+// Music visualizer made from the image. It is cut into bars columns and each one becomes
+// a bar rising from the bottom, as tall as the column is bright and in its average color
+func equalizerBars(img image.Image, width, height int, bars int) draw.Image {
+	newImg := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(newImg, newImg.Bounds(), image.NewUniform(color.RGBA{0, 0, 0, 255}), image.Point{}, draw.Src)
+	bars = max(1, min(bars, width))
+
+	for i := 0; i < bars; i++ {
+		column := image.Rect(i*width/bars, 0, (i+1)*width/bars, height)
+		avg := averageColor(img, column)
+		avg.A = 255
+		barHeight := int(math.Round(luma(avg) / 255 * float64(height)))
+
+		// Leave a one pixel gap between the bars when there is room for it
+		bar := image.Rect(column.Min.X, height-barHeight, column.Max.X, height)
+		if bar.Dx() > 2 {
+			bar.Max.X--
+		}
+		draw.Draw(newImg, bar, image.NewUniform(avg), image.Point{}, draw.Src)
+	}
+	return newImg
+}
+
+// Keeps the colors inside the rectangle from (x0, y0) to (x1, y1) and turns everything
+// outside it gray, fading over a soft edge. A rectangle that spotlightBounds rejects
+// leaves the image as it is
+func spotlightRect(img image.Image, width, height int, x0, y0, x1, y1 int) draw.Image {
+	newImg := image.NewRGBA(image.Rect(0, 0, width, height))
+	spot, err := spotlightBounds(width, height, x0, y0, x1, y1)
+	if err != nil {
+		draw.Draw(newImg, newImg.Bounds(), img, image.Point{}, draw.Src)
+		return newImg
+	}
+	feather := float64(max(4, min(width, height)/20))
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			col := img.At(x, y)
+			r, g, b, a := col.RGBA()
+
+			// Distance to the rectangle decides how gray the pixel gets
+			dx := max(spot.Min.X-x, 0, x-(spot.Max.X-1))
+			dy := max(spot.Min.Y-y, 0, y-(spot.Max.Y-1))
+			t := math.Min(math.Hypot(float64(dx), float64(dy))/feather, 1)
+			gray := luma(col)
+			mix := func(c uint32) uint8 { return uint8(math.Round(float64(c>>8)*(1-t) + gray*t)) }
+			newImg.SetRGBA(x, y, color.RGBA{mix(r), mix(g), mix(b), uint8(a >> 8)})
+		}
+	}
+	return newImg
+}
+
+// The spotlight rectangle from (x0, y0) to (x1, y1), or an error when it is empty or
+// reaches outside the width x height image
+func spotlightBounds(width, height, x0, y0, x1, y1 int) (image.Rectangle, error) {
+	spot := image.Rect(x0, y0, x1, y1)
+	if spot.Empty() {
+		return spot, fmt.Errorf("spotlight %v is empty", spot)
+	}
+	if !spot.In(image.Rect(0, 0, width, height)) {
+		return spot, fmt.Errorf("spotlight %v is outside the %dx%d image", spot, width, height)
+	}
+	return spot, nil
+}
+
+// Sorts every pixel by brightness and lays them out again along a Hilbert curve, darkest
+// first. Nothing is blended, the new image holds exactly the pixels of the old one
+func rankScramble(img image.Image, width, height int) draw.Image {
+	newImg := image.NewRGBA(image.Rect(0, 0, width, height))
+	lum := lumaMap(img, width, height)
+	order := make([]int, width*height)
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool { return lum[order[i]] < lum[order[j]] })
+
+	for i, pt := range hilbertPoints(width, height) {
+		src := order[i]
+		newImg.Set(pt.X, pt.Y, img.At(src%width, src/width))
+	}
+	return newImg
+}
+
+// Every point of a width by height grid in the order a Hilbert curve visits them. The
+// curve covers the smallest power of two square around the grid, points outside are skipped
+func hilbertPoints(width, height int) []image.Point {
+	side := 1
+	for side < width || side < height {
+		side *= 2
+	}
+	points := make([]image.Point, 0, width*height)
+	for d := 0; d < side*side; d++ {
+		// Walk from the smallest sub square up, rotating the quadrant as needed
+		x, y, t := 0, 0, d
+		for s := 1; s < side; s *= 2 {
+			rx := 1 & (t / 2)
+			ry := 1 & (t ^ rx)
+			if ry == 0 {
+				if rx == 1 {
+					x, y = s-1-x, s-1-y
+				}
+				x, y = y, x
+			}
+			x += s * rx
+			y += s * ry
+			t /= 4
+		}
+		if x < width && y < height {
+			points = append(points, image.Point{x, y})
+		}
+	}
+	return points
+}
+
+// Clusters the colors into k groups with k-means and paints every pixel with the average
+// color of its group. The seed picks the starting centers so the same seed gives the same poster
+func kmeansPosterize(img image.Image, width, height int, k int, seed int64) draw.Image {
+	newImg := image.NewRGBA(image.Rect(0, 0, width, height))
+	pixels := make([][3]float64, width*height)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			r, g, b, _ := img.At(x, y).RGBA()
+			pixels[y*width+x] = [3]float64{float64(r >> 8), float64(g >> 8), float64(b >> 8)}
+		}
+	}
+	if len(pixels) == 0 {
+		return newImg
+	}
+	k = max(1, min(k, len(pixels)))
+
+	// Start from k different pixels picked at random
+	rng := rand.New(rand.NewSource(seed))
+	centers := make([][3]float64, k)
+	for i, p := range rng.Perm(len(pixels))[:k] {
+		centers[i] = pixels[p]
+	}
+
+	cluster := make([]int, len(pixels))
+	for iter := 0; iter < 20; iter++ {
+		changed := false
+		for i, p := range pixels {
+			best, bestDist := 0, math.Inf(1)
+			for c, center := range centers {
+				dr, dg, db := p[0]-center[0], p[1]-center[1], p[2]-center[2]
+				if dist := dr*dr + dg*dg + db*db; dist < bestDist {
+					best, bestDist = c, dist
+				}
+			}
+			if cluster[i] != best || iter == 0 {
+				cluster[i] = best
+				changed = true
+			}
+		}
+		if !changed {
+			break
+		}
+
+		// Move every center to the average of its pixels, empty clusters stay put
+		sums := make([][3]float64, k)
+		counts := make([]int, k)
+		for i, p := range pixels {
+			c := cluster[i]
+			sums[c][0] += p[0]
+			sums[c][1] += p[1]
+			sums[c][2] += p[2]
+			counts[c]++
+		}
+		for c := range centers {
+			if counts[c] > 0 {
+				n := float64(counts[c])
+				centers[c] = [3]float64{sums[c][0] / n, sums[c][1] / n, sums[c][2] / n}
+			}
+		}
+	}
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			center := centers[cluster[y*width+x]]
+			_, _, _, a := img.At(x, y).RGBA()
+			newImg.SetRGBA(x, y, color.RGBA{
+				uint8(math.Round(center[0])),
+				uint8(math.Round(center[1])),
+				uint8(math.Round(center[2])),
+				uint8(a >> 8),
+			})
+		}
+	}
+	return newImg
+}
+
+// Mirrors the image left to right like a funhouse mirror and lets the rows sway along
+// a sine wave. An amplitude of 0 gives a plain mirror image
+func funhouse(img image.Image, width, height int, amplitude, frequency float64) draw.Image {
+	return waveImage(flipHorizontal(img, width, height), width, height, amplitude, frequency)
+}
+
+// The image as an *image.RGBA starting at (0, 0), copied only when it isn't one already
+func asRGBA(img image.Image, width, height int) *image.RGBA {
+	if rgba, ok := img.(*image.RGBA); ok && rgba.Rect == image.Rect(0, 0, width, height) {
+		return rgba
+	}
+	rgba := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(rgba, rgba.Rect, img, img.Bounds().Min, draw.Src)
+	return rgba
+}
+
+// Mirrors the image left to right, the colors stay as they are
+func flipHorizontal(img image.Image, width, height int) draw.Image {
+	src := asRGBA(img, width, height)
+	newImg := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			newImg.SetRGBA(x, y, src.RGBAAt(width-1-x, y))
+		}
+	}
+	return newImg
+}
+
+// Turns the image upside down by mirroring it top to bottom, the colors stay as they are
+func flipVertical(img image.Image, width, height int) draw.Image {
+	src := asRGBA(img, width, height)
+	newImg := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			newImg.SetRGBA(x, y, src.RGBAAt(x, height-1-y))
+		}
+	}
+	return newImg
+}
+
+// Black and white, every channel set to the luminance of the pixel
+func grayscale(img image.Image, width, height int) draw.Image {
+	newImg := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			col := img.At(x, y)
+			_, _, _, a := col.RGBA()
+			gray := uint8(math.Round(luma(col)))
+			newImg.SetRGBA(x, y, color.RGBA{gray, gray, gray, uint8(a >> 8)})
+		}
+	}
+	return newImg
+}
+
+// Old photo look using the usual sepia matrix. The matrix works on the straight colors,
+// so see-through pixels are un-premultiplied first and premultiplied again after
+func sepia(img image.Image, width, height int) draw.Image {
+	newImg := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			r, g, b, a := img.At(x, y).RGBA()
+			if a == 0 {
+				continue
+			}
+			fr, fg, fb := float64(r)*255/float64(a), float64(g)*255/float64(a), float64(b)*255/float64(a)
+			sr := clamp(int(math.Round(0.393*fr + 0.769*fg + 0.189*fb)))
+			sg := clamp(int(math.Round(0.349*fr + 0.686*fg + 0.168*fb)))
+			sb := clamp(int(math.Round(0.272*fr + 0.534*fg + 0.131*fb)))
+			alpha := int(a >> 8)
+			newImg.SetRGBA(x, y, color.RGBA{
+				uint8((sr*alpha + 127) / 255),
+				uint8((sg*alpha + 127) / 255),
+				uint8((sb*alpha + 127) / 255),
+				uint8(alpha),
+			})
+		}
+	}
+	return newImg
+}
+
+// Soft focus blur reaching radius pixels out. The kernel is applied across and then
+// down, which is much cheaper than the full square, and samples past the edge use the edge
+func gaussianBlur(img image.Image, width, height int, radius float64) draw.Image {
+	return separableBlur(img, width, height, gaussianKernel(radius/3))
+}
+
+// Neon outlines, the strength of the Sobel gradient of the luminance as a gray level
+func edgeDetect(img image.Image, width, height int) draw.Image {
+	newImg := image.NewRGBA(image.Rect(0, 0, width, height))
+	lum := lumaMap(img, width, height)
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			gx, gy := sobel(lum, width, height, x, y)
+			edge := uint8(clamp(int(math.Round(math.Hypot(gx, gy)))))
+			newImg.SetRGBA(x, y, color.RGBA{edge, edge, edge, 255})
+		}
+	}
+	return newImg
+}
+
+// Blocky mosaic, every block x block square filled with its average color. Blocks along
+// the right and bottom edge are cut short when the size doesn't divide the image
+func pixelate(img image.Image, width, height int, block int) draw.Image {
+	newImg := image.NewRGBA(image.Rect(0, 0, width, height))
+	if block < 1 {
+		block = 8
+	}
+
+	for by := 0; by < height; by += block {
+		for bx := 0; bx < width; bx += block {
+			rect := image.Rect(bx, by, min(bx+block, width), min(by+block, height))
+			draw.Draw(newImg, rect, image.NewUniform(averageColor(img, rect)), image.Point{}, draw.Src)
+		}
+	}
+	return newImg
+}
+
+// Turns the image degrees clockwise around its center on a canvas of the same size, so the
+// corners get cropped. Every pixel looks up where it came from and samples it smoothly,
+// spots that came from outside the image get the background, transparent for color.RGBA{}
+func rotate(img image.Image, width, height int, degrees float64, background color.RGBA) draw.Image {
+	newImg := image.NewRGBA(image.Rect(0, 0, width, height))
+	cx, cy := float64(width-1)/2, float64(height-1)/2
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			sx, sy := rotatePoint(float64(x), float64(y), cx, cy, -degrees)
+			if sx < -0.5 || sy < -0.5 || sx > float64(width)-0.5 || sy > float64(height)-0.5 {
+				newImg.SetRGBA(x, y, background)
+				continue
+			}
+			newImg.SetRGBA(x, y, bilinear(img, width, height, sx, sy))
+		}
+	}
+	return newImg
+}
@@ -0,0 +1,1653 @@
+package wackygif
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"math/rand"
+	"sync"
+	"testing"
+)
+
+// Opaque test image with every pixel picked by fill
+func newTestImage(width, height int, fill func(x, y int) color.RGBA) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.SetRGBA(x, y, fill(x, y))
+		}
+	}
+	return img
+}
+
+func uniformImage(width, height int, col color.RGBA) *image.RGBA {
+	return newTestImage(width, height, func(x, y int) color.RGBA { return col })
+}
+
+// Opaque random colors, the same seed gives the same image
+func noiseImage(width, height int, seed int64) *image.RGBA {
+	rng := rand.New(rand.NewSource(seed))
+	return newTestImage(width, height, func(x, y int) color.RGBA {
+		return color.RGBA{uint8(rng.Intn(256)), uint8(rng.Intn(256)), uint8(rng.Intn(256)), 255}
+	})
+}
+
+// Gray ramp getting brighter by step per column
+func rampImage(width, height int, step int) *image.RGBA {
+	return newTestImage(width, height, func(x, y int) color.RGBA {
+		v := uint8(clamp(x * step))
+		return color.RGBA{v, v, v, 255}
+	})
+}
+
+func rgbaAt(img image.Image, x, y int) color.RGBA {
+	return color.RGBAModel.Convert(img.At(x, y)).(color.RGBA)
+}
+
+func assertSameImage(t *testing.T, got, want image.Image) {
+	t.Helper()
+	if got.Bounds() != want.Bounds() {
+		t.Fatalf("bounds %v, want %v", got.Bounds(), want.Bounds())
+	}
+	b := want.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if g, w := rgbaAt(got, x, y), rgbaAt(want, x, y); g != w {
+				t.Fatalf("pixel (%d, %d) is %v, want %v", x, y, g, w)
+			}
+		}
+	}
+}
+
+func differentImages(a, b image.Image) bool {
+	bounds := a.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			if rgbaAt(a, x, y) != rgbaAt(b, x, y) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func isGray(c color.RGBA) bool { return c.R == c.G && c.G == c.B }
+
+func TestShapeMaskCircle(t *testing.T) {
+	const size = 20
+	src := noiseImage(size, size, 1)
+	out := shapeMask(src, size, size, "circle")
+
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			u := 2*(float64(x)+0.5)/size - 1
+			v := 1 - 2*(float64(y)+0.5)/size
+			got := rgbaAt(out, x, y)
+			if u*u+v*v <= 1 {
+				if want := src.RGBAAt(x, y); got != want {
+					t.Fatalf("inside pixel (%d, %d) is %v, want the source %v", x, y, got, want)
+				}
+			} else if got != (color.RGBA{}) {
+				t.Fatalf("outside pixel (%d, %d) is %v, want transparent", x, y, got)
+			}
+		}
+	}
+}
+
+func TestSeamSquishKeepsStripe(t *testing.T) {
+	const width, height = 30, 8
+	white := color.RGBA{255, 255, 255, 255}
+	src := newTestImage(width, height, func(x, y int) color.RGBA {
+		if x == 14 || x == 15 {
+			return white
+		}
+		return color.RGBA{100, 100, 100, 255}
+	})
+	out := seamSquish(src, width, height, 20)
+
+	// The stripe survives straight down the image, and is stretched by the 30/20 scale
+	// back up, so all the removed seams came out of the flat gray
+	var stripe []int
+	for x := 0; x < width; x++ {
+		if rgbaAt(out, x, 0) == white {
+			stripe = append(stripe, x)
+		}
+	}
+	if len(stripe) != 3 {
+		t.Fatalf("stripe is %d columns wide, want 3 after squishing to 20 and stretching back", len(stripe))
+	}
+	for y := 1; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if (rgbaAt(out, x, y) == white) != (rgbaAt(out, x, 0) == white) {
+				t.Fatalf("stripe is not straight at (%d, %d)", x, y)
+			}
+		}
+	}
+}
+
+func TestLEDWall(t *testing.T) {
+	const size, cell, gap = 12, 4, 1
+	src := noiseImage(size, size, 2)
+	out := ledWall(src, size, size, cell, gap)
+
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			got := rgbaAt(out, x, y)
+			if x%cell >= cell-gap || y%cell >= cell-gap {
+				if got != (color.RGBA{0, 0, 0, 255}) {
+					t.Fatalf("gap pixel (%d, %d) is %v, want black", x, y, got)
+				}
+				continue
+			}
+			bx, by := x/cell*cell, y/cell*cell
+			if want := averageColor(src, image.Rect(bx, by, bx+cell, by+cell)); got != want {
+				t.Fatalf("lit pixel (%d, %d) is %v, want the block average %v", x, y, got, want)
+			}
+		}
+	}
+}
+
+func TestFrostedGlass(t *testing.T) {
+	src := noiseImage(16, 16, 3)
+	assertSameImage(t, frostedGlass(src, 16, 16, 0, 1), src)
+
+	a := frostedGlass(src, 16, 16, 3, 7)
+	assertSameImage(t, frostedGlass(src, 16, 16, 3, 7), a)
+	if !differentImages(a, frostedGlass(src, 16, 16, 3, 8)) {
+		t.Error("a different seed gave the same glass")
+	}
+}
+
+func TestStreamlines(t *testing.T) {
+	const size, step = 32, 8
+	black := color.RGBA{0, 0, 0, 255}
+
+	flat := streamlines(uniformImage(size, size, color.RGBA{120, 120, 120, 255}), size, size, step)
+	assertSameImage(t, flat, uniformImage(size, size, black))
+
+	// The ramp only changes along x, so every line runs horizontally through its seed row.
+	// Rounding in the gradient can let a line drift by a row over its length
+	out := streamlines(rampImage(size, size, 8), size, size, step)
+	drawn := make(map[int]bool)
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			if rgbaAt(out, x, y) == black {
+				continue
+			}
+			if d := y%step - step/2; d < -1 || d > 1 {
+				t.Fatalf("line pixel (%d, %d) is off the seed rows", x, y)
+			}
+			drawn[y/step] = true
+		}
+	}
+	for y := step / 2; y < size; y += step {
+		if !drawn[y/step] {
+			t.Errorf("no line drawn through seed row %d", y)
+		}
+	}
+}
+
+func TestRorschach(t *testing.T) {
+	const width, height = 21, 10
+	out := rorschach(noiseImage(width, height, 4), width, height, 128)
+	ink, paper := color.RGBA{20, 20, 25, 255}, color.RGBA{240, 235, 225, 255}
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			got := rgbaAt(out, x, y)
+			if got != ink && got != paper {
+				t.Fatalf("pixel (%d, %d) is %v, want ink or paper", x, y, got)
+			}
+			if mirror := rgbaAt(out, width-1-x, y); got != mirror {
+				t.Fatalf("pixel (%d, %d) is %v but its mirror is %v", x, y, got, mirror)
+			}
+		}
+	}
+}
+
+func TestHueSortTiles(t *testing.T) {
+	const size, cell = 10, 4
+	src := noiseImage(size, size, 5)
+	out := hueSortTiles(src, size, size, cell)
+
+	for by := 0; by < size; by += cell {
+		for bx := 0; bx < size; bx += cell {
+			tile := image.Rect(bx, by, bx+cell, by+cell).Intersect(src.Bounds())
+			count := make(map[color.RGBA]int)
+			prevHue := -1.0
+			for y := tile.Min.Y; y < tile.Max.Y; y++ {
+				for x := tile.Min.X; x < tile.Max.X; x++ {
+					count[src.RGBAAt(x, y)]++
+					got := rgbaAt(out, x, y)
+					count[got]--
+					h, _, _ := rgbToHSV(got)
+					if h < prevHue {
+						t.Fatalf("hue drops to %.1f at (%d, %d) in tile %v", h, x, y, tile)
+					}
+					prevHue = h
+				}
+			}
+			for col, n := range count {
+				if n != 0 {
+					t.Fatalf("tile %v is not a permutation, %v is off by %d", tile, col, n)
+				}
+			}
+		}
+	}
+}
+
+func TestQuadTree(t *testing.T) {
+	const size = 16
+	flat := uniformImage(size, size, color.RGBA{30, 60, 90, 255})
+	assertSameImage(t, quadTree(flat, size, size, 10), flat)
+
+	// Every region of random pixels has some variance, so a threshold of 0
+	// keeps splitting down to single pixels which are their own average
+	noise := noiseImage(size, size, 6)
+	assertSameImage(t, quadTree(noise, size, size, 0), noise)
+}
+
+func TestStarTrails(t *testing.T) {
+	const size = 21
+	dark := uniformImage(size, size, color.RGBA{50, 50, 50, 255})
+	assertSameImage(t, starTrails(dark, size, size, 90, 30), dark)
+
+	src := uniformImage(size, size, color.RGBA{50, 50, 50, 255})
+	src.SetRGBA(15, 10, color.RGBA{255, 255, 255, 255})
+	out := starTrails(src, size, size, 90, 30)
+
+	// The trail stays on the circle of radius 5 around the center and reaches a quarter turn
+	changed := 0
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			if rgbaAt(out, x, y) == src.RGBAAt(x, y) {
+				continue
+			}
+			changed++
+			if r := math.Hypot(float64(x-10), float64(y-10)); math.Abs(r-5) > 1 {
+				t.Fatalf("trail pixel (%d, %d) is %.1f from the center, want about 5", x, y, r)
+			}
+		}
+	}
+	if changed < 5 {
+		t.Errorf("trail has %d pixels, want an arc", changed)
+	}
+	if rgbaAt(out, 10, 15) == src.RGBAAt(10, 15) {
+		t.Error("trail does not reach the end of the quarter turn at (10, 15)")
+	}
+}
+
+func TestTextMask(t *testing.T) {
+	const width, height = 60, 20
+	src := noiseImage(width, height, 7)
+	out := textMask(src, width, height, "W")
+
+	glyph, background := 0, 0
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			switch rgbaAt(out, x, y) {
+			case src.RGBAAt(x, y):
+				glyph++
+			case color.RGBA{0, 0, 0, 255}:
+				background++
+			default:
+				t.Fatalf("pixel (%d, %d) is neither the source nor the background", x, y)
+			}
+		}
+	}
+	if glyph == 0 || background == 0 {
+		t.Errorf("got %d glyph and %d background pixels, want both", glyph, background)
+	}
+}
+
+func TestChannelGamma(t *testing.T) {
+	src := noiseImage(10, 10, 8)
+	assertSameImage(t, channelGamma(src, 10, 10, 1.8, 1.8, 1.8), adjustGamma(src, 10, 10, 1.8))
+
+	gray := uniformImage(1, 1, color.RGBA{128, 128, 128, 255})
+	got := rgbaAt(channelGamma(gray, 1, 1, 2, 1, 0.5), 0, 0)
+	if got.R <= 128 || got.G != 128 || got.B >= 128 {
+		t.Errorf("gray with gammas 2, 1, 0.5 became %v, want more red and less blue", got)
+	}
+}
+
+func TestMeshWarp(t *testing.T) {
+	const size, grid = 21, 3
+	src := noiseImage(size, size, 9)
+	displacements := make([][2]float64, grid*grid)
+	assertSameImage(t, meshWarp(src, size, size, grid, displacements, 0), src)
+
+	// Moving the top left control point only touches the cell next to it
+	displacements[0] = [2]float64{4, 4}
+	out := meshWarp(src, size, size, grid, displacements, 0)
+	moved := false
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			same := rgbaAt(out, x, y) == src.RGBAAt(x, y)
+			if (x >= 10 || y >= 10) && !same {
+				t.Fatalf("pixel (%d, %d) outside the displaced cell changed", x, y)
+			}
+			moved = moved || !same
+		}
+	}
+	if !moved {
+		t.Error("displacing the corner did not warp anything")
+	}
+}
+
+func TestColorBleed(t *testing.T) {
+	src := noiseImage(12, 12, 10)
+	assertSameImage(t, colorBleed(src, 12, 12, 3, 0), src)
+
+	gray := color.RGBA{128, 128, 128, 255}
+	lone := uniformImage(11, 11, gray)
+	lone.SetRGBA(5, 5, color.RGBA{255, 0, 0, 255})
+	out := colorBleed(lone, 11, 11, 2, 0.5)
+
+	if got := rgbaAt(out, 6, 5); got.R <= gray.R || got.G >= gray.G {
+		t.Errorf("neighbour of the red pixel is %v, want it tinted red", got)
+	}
+	if got := rgbaAt(out, 0, 0); got != gray {
+		t.Errorf("far away pixel is %v, want it untouched", got)
+	}
+}
+
+func TestNestedSquares(t *testing.T) {
+	const size = 41
+	src := noiseImage(size, size, 11)
+	assertSameImage(t, nestedSquares(src, size, size, 1), src)
+
+	// The corners are outside every smaller copy, so only the full size one shows there
+	out := nestedSquares(src, size, size, 3)
+	for _, p := range []image.Point{{0, 0}, {size - 1, 0}, {0, size - 1}, {size - 1, size - 1}} {
+		if got, want := rgbaAt(out, p.X, p.Y), src.RGBAAt(p.X, p.Y); got != want {
+			t.Errorf("corner %v is %v, want the background copy %v", p, got, want)
+		}
+	}
+	if rgbaAt(out, 23, 20) == src.RGBAAt(23, 20) {
+		t.Error("no rotated copy on top near the center")
+	}
+}
+
+func TestCMYKHalftoneCyan(t *testing.T) {
+	const size = 32
+	out := cmykHalftone(uniformImage(size, size, color.RGBA{0, 255, 255, 255}), size, size, 6)
+
+	dots := 0
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			got := rgbaAt(out, x, y)
+			if got.G != 255 || got.B != 255 {
+				t.Fatalf("pixel (%d, %d) is %v, only the cyan screen should print", x, y, got)
+			}
+			if got.R == 0 {
+				dots++
+			}
+		}
+	}
+	if dots == 0 {
+		t.Error("no cyan dots printed")
+	}
+}
+
+func TestMipBlur(t *testing.T) {
+	const size = 32
+	src := noiseImage(size, size, 12)
+	assertSameImage(t, mipBlur(src, size, size, 0), src)
+
+	want := averageColor(src, src.Bounds())
+	prev := colorVariance(src, src.Bounds())
+	for levels := 1; levels <= 3; levels++ {
+		out := mipBlur(src, size, size, levels)
+		variance := colorVariance(out, src.Bounds())
+		if variance >= prev {
+			t.Errorf("levels %d has variance %.1f, want less than %.1f", levels, variance, prev)
+		}
+		prev = variance
+
+		// Every halving rounds down a little, so allow a few steps of drift
+		got := averageColor(out, src.Bounds())
+		if math.Abs(float64(got.R)-float64(want.R)) > 4 || math.Abs(float64(got.G)-float64(want.G)) > 4 || math.Abs(float64(got.B)-float64(want.B)) > 4 {
+			t.Errorf("levels %d changed the average color to %v, want about %v", levels, got, want)
+		}
+	}
+}
+
+func TestCylinderWrap(t *testing.T) {
+	const width, height = 33, 4
+	src := rampImage(width, height, 7)
+	assertSameImage(t, cylinderWrap(src, width, height, 0), src)
+
+	out := cylinderWrap(src, width, height, 0.8)
+	last := int(src.RGBAAt(width-1, 0).R)
+	for x := 0; x < width; x++ {
+		left, right := int(rgbaAt(out, x, 0).R), int(rgbaAt(out, width-1-x, 0).R)
+		if d := left + right - last; d < -1 || d > 1 {
+			t.Fatalf("columns %d and %d are not mirror images of the wrap (%d + %d)", x, width-1-x, left, right)
+		}
+	}
+
+	// Towards the edges a column covers more of the source than in the middle
+	step := func(x int) int { return int(rgbaAt(out, x+1, 0).R) - int(rgbaAt(out, x, 0).R) }
+	if step(0) <= step(width/2) {
+		t.Errorf("edge step %d is not bigger than the center step %d", step(0), step(width/2))
+	}
+}
+
+func TestRowSort(t *testing.T) {
+	const width, height = 8, 12
+	src := noiseImage(width, height, 13)
+	out := rowSort(src, width, height)
+
+	row := func(img image.Image, y int) [width]color.RGBA {
+		var r [width]color.RGBA
+		for x := range r {
+			r[x] = rgbaAt(img, x, y)
+		}
+		return r
+	}
+	unused := make(map[[width]color.RGBA]int)
+	for y := 0; y < height; y++ {
+		unused[row(src, y)]++
+	}
+
+	prev := -1.0
+	for y := 0; y < height; y++ {
+		r := row(out, y)
+		if unused[r] == 0 {
+			t.Fatalf("output row %d is not one of the input rows", y)
+		}
+		unused[r]--
+		sum := 0.0
+		for _, c := range r {
+			sum += luma(c)
+		}
+		if sum < prev {
+			t.Fatalf("row %d is darker than the row above it", y)
+		}
+		prev = sum
+	}
+}
+
+func TestChromaEdges(t *testing.T) {
+	const size = 20
+	white := color.RGBA{255, 255, 255, 255}
+	vertical := newTestImage(size, size, func(x, y int) color.RGBA {
+		if x < size/2 {
+			return color.RGBA{0, 0, 0, 255}
+		}
+		return white
+	})
+	horizontal := newTestImage(size, size, func(x, y int) color.RGBA {
+		if y < size/2 {
+			return color.RGBA{0, 0, 0, 255}
+		}
+		return white
+	})
+
+	for _, tc := range []struct {
+		name    string
+		img     image.Image
+		edge    image.Point
+		wantHue float64
+	}{
+		{"vertical edge", vertical, image.Point{size / 2, 5}, 0},
+		{"horizontal edge", horizontal, image.Point{5, size / 2}, 90},
+	} {
+		out := chromaEdges(tc.img, size, size)
+		if got := rgbaAt(out, 2, 2); got != (color.RGBA{0, 0, 0, 255}) {
+			t.Errorf("%s: flat pixel is %v, want black", tc.name, got)
+		}
+		h, s, v := rgbToHSV(rgbaAt(out, tc.edge.X, tc.edge.Y))
+		if math.Abs(h-tc.wantHue) > 1 || s < 0.99 || v < 0.99 {
+			t.Errorf("%s: edge has hue %.1f, saturation %.2f and value %.2f, want hue %.0f at full strength", tc.name, h, s, v, tc.wantHue)
+		}
+	}
+}
+
+func TestLowPoly(t *testing.T) {
+	const width, height, points, seed = 40, 30, 20, 3
+	src := noiseImage(width, height, 14)
+	out := lowPoly(src, width, height, points, seed)
+	assertSameImage(t, lowPoly(src, width, height, points, seed), out)
+
+	// Rebuild the same triangles and hand every pixel to the first one holding its center
+	rng := rand.New(rand.NewSource(seed))
+	pts := [][2]float64{{0, 0}, {width, 0}, {0, height}, {width, height}}
+	for i := 0; i < points; i++ {
+		pts = append(pts, [2]float64{rng.Float64() * width, rng.Float64() * height})
+	}
+	triangles := delaunay(pts)
+	fills := make(map[int]color.RGBA)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			for i, tri := range triangles {
+				poly := [][2]float64{pts[tri[0]], pts[tri[1]], pts[tri[2]]}
+				if !insidePolygon(poly, float64(x)+0.5, float64(y)+0.5) {
+					continue
+				}
+				got := rgbaAt(out, x, y)
+				if fill, ok := fills[i]; ok && fill != got {
+					t.Fatalf("triangle %d has both %v and %v", i, fill, got)
+				}
+				fills[i] = got
+				break
+			}
+		}
+	}
+	if len(fills) < 2 {
+		t.Errorf("only %d triangles filled", len(fills))
+	}
+}
+
+func TestThirdsShift(t *testing.T) {
+	const width, height, shift = 20, 9, 5
+	src := noiseImage(width, height, 15)
+	assertSameImage(t, thirdsShift(src, width, height, 0), src)
+
+	out := thirdsShift(src, width, height, shift)
+	for y := 0; y < height; y++ {
+		offset := []int{0, shift, -shift}[y/3]
+		for x := 0; x < width; x++ {
+			srcX := ((x-offset)%width + width) % width
+			if got, want := rgbaAt(out, x, y), src.RGBAAt(srcX, y); got != want {
+				t.Fatalf("pixel (%d, %d) is %v, want %v from %d pixels to the left", x, y, got, want, offset)
+			}
+		}
+	}
+}
+
+func TestDropletLens(t *testing.T) {
+	const size = 32
+	src := noiseImage(size, size, 16)
+	assertSameImage(t, dropletLens(src, size, size, 4, 0), src)
+
+	// A single lens over a ramp, the magnified pixels show what is closer to its center
+	ramp := rampImage(size, size, 8)
+	out := dropletLens(ramp, size, size, 1, 1)
+	center := 15.5 * 8
+	for x := 0; x < size; x++ {
+		got, was := float64(rgbaAt(out, x, 16).R), float64(ramp.RGBAAt(x, 16).R)
+		if math.Abs(got-center) > math.Abs(was-center)+1 {
+			t.Fatalf("column %d shows %v, further from the lens center than the source %v", x, got, was)
+		}
+	}
+	if !differentImages(out, ramp) {
+		t.Error("lens did not magnify anything")
+	}
+}
+
+func TestHeightMap(t *testing.T) {
+	const size, rows, scale = 40, 4, 5
+	white := color.RGBA{255, 255, 255, 255}
+	baselines := map[int]bool{8: true, 16: true, 24: true, 32: true}
+
+	flat := heightMap(uniformImage(size, size, color.RGBA{0, 0, 0, 255}), size, size, rows, scale)
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			if (rgbaAt(flat, x, y) == white) != baselines[y] {
+				t.Fatalf("pixel (%d, %d) breaks the straight evenly spaced lines", x, y)
+			}
+		}
+	}
+
+	// A bright band lifts the lines by scale where they cross it
+	band := newTestImage(size, size, func(x, y int) color.RGBA {
+		if x >= 10 && x < 20 {
+			return white
+		}
+		return color.RGBA{0, 0, 0, 255}
+	})
+	out := heightMap(band, size, size, rows, scale)
+	for baseline := range baselines {
+		if rgbaAt(out, 15, baseline-scale) != white || rgbaAt(out, 15, baseline) == white {
+			t.Errorf("line at %d is not lifted over the band", baseline)
+		}
+		if rgbaAt(out, 30, baseline) != white {
+			t.Errorf("line at %d is lifted outside the band", baseline)
+		}
+	}
+}
+
+func TestBrightnessWarp(t *testing.T) {
+	const width, height, amplitude = 32, 4, 5
+	src := noiseImage(width, height, 17)
+	assertSameImage(t, brightnessWarp(src, width, height, 0), src)
+
+	// Every pixel moves by the sine of its own brightness, not by its position
+	ramp := rampImage(width, height, 8)
+	out := brightnessWarp(ramp, width, height, amplitude)
+	for x := 0; x < width; x++ {
+		offset := int(math.Round(amplitude * math.Sin(2*math.Pi*luma(ramp.RGBAAt(x, 0))/255)))
+		want := ramp.RGBAAt(max(0, min(x+offset, width-1)), 0)
+		if got := rgbaAt(out, x, 0); got != want {
+			t.Fatalf("column %d is %v, want %v from offset %d", x, got, want, offset)
+		}
+	}
+	if !differentImages(out, ramp) {
+		t.Error("nothing moved")
+	}
+}
+
+func TestPhotocopy(t *testing.T) {
+	const size = 32
+	paper, toner := color.RGBA{250, 250, 245, 255}, color.RGBA{15, 15, 15, 255}
+
+	gray := photocopy(uniformImage(size, size, color.RGBA{128, 128, 128, 255}), size, size, 2, 0.2)
+	white := 0
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			if rgbaAt(gray, x, y) == paper {
+				white++
+			}
+		}
+	}
+	if white < size*size*9/10 {
+		t.Errorf("flat gray came out %d/%d white, want most of it", white, size*size)
+	}
+
+	edge := newTestImage(size, size, func(x, y int) color.RGBA {
+		if x < size/2 {
+			return color.RGBA{0, 0, 0, 255}
+		}
+		return color.RGBA{255, 255, 255, 255}
+	})
+	out := photocopy(edge, size, size, 1, 0)
+	for y := 0; y < size; y++ {
+		if rgbaAt(out, size/2-1, y) != toner || rgbaAt(out, size/2, y) != toner {
+			t.Fatalf("edge in row %d is not a black line", y)
+		}
+		if rgbaAt(out, size-4, y) != paper {
+			t.Fatalf("flat white in row %d picked up toner", y)
+		}
+	}
+}
+
+func TestDOFBlur(t *testing.T) {
+	const size, focus = 41, 8
+	src := noiseImage(size, size, 18)
+	out := dofBlur(src, size, size, focus, 3)
+
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			if math.Hypot(float64(x-20), float64(y-20)) > focus {
+				continue
+			}
+			if got, want := rgbaAt(out, x, y), src.RGBAAt(x, y); got != want {
+				t.Fatalf("pixel (%d, %d) in focus is %v, want the source %v", x, y, got, want)
+			}
+		}
+	}
+	corner := image.Rect(0, 0, 8, 8)
+	if got, was := colorVariance(out, corner), colorVariance(src, corner); got > was/2 {
+		t.Errorf("corner variance went from %.0f to %.0f, want it blurred", was, got)
+	}
+}
+
+func TestLumaSplit(t *testing.T) {
+	const width, height = 20, 4
+	src := noiseImage(width, height, 19)
+	assertSameImage(t, lumaSplit(src, width, height, 0, 0), src)
+
+	dark, bright := color.RGBA{60, 20, 20, 255}, color.RGBA{200, 220, 240, 255}
+	split := newTestImage(width, height, func(x, y int) color.RGBA {
+		if x < width/2 {
+			return dark
+		}
+		return bright
+	})
+	out := lumaSplit(split, width, height, 3, -3)
+
+	// The shadows push their red to the right and the highlights their blue to the left
+	if got := rgbaAt(out, width/2, 0); got != (color.RGBA{dark.R, bright.G, bright.B, 255}) {
+		t.Errorf("first bright pixel is %v, want red from the shadow", got)
+	}
+	if got := rgbaAt(out, width/2-1, 0); got != (color.RGBA{dark.R, dark.G, bright.B, 255}) {
+		t.Errorf("last dark pixel is %v, want blue from the highlight", got)
+	}
+	if got := rgbaAt(out, 0, 0); got != dark {
+		t.Errorf("pixel away from the boundary is %v, want it untouched", got)
+	}
+}
+
+func TestStipple(t *testing.T) {
+	const size = 40
+	black := color.RGBA{0, 0, 0, 255}
+	dots := func(img image.Image, rect image.Rectangle) int {
+		n := 0
+		for y := rect.Min.Y; y < rect.Max.Y; y++ {
+			for x := rect.Min.X; x < rect.Max.X; x++ {
+				if rgbaAt(img, x, y) == black {
+					n++
+				}
+			}
+		}
+		return n
+	}
+
+	halves := newTestImage(size, size, func(x, y int) color.RGBA {
+		if x < size/2 {
+			return color.RGBA{50, 50, 50, 255}
+		}
+		return color.RGBA{200, 200, 200, 255}
+	})
+	out := stipple(halves, size, size, 1)
+	darkDots := dots(out, image.Rect(0, 0, size/2, size))
+	lightDots := dots(out, image.Rect(size/2, 0, size, size))
+	if darkDots <= lightDots {
+		t.Errorf("dark half has %d dots and light half %d, want more in the dark", darkDots, lightDots)
+	}
+
+	white := stipple(uniformImage(size, size, color.RGBA{255, 255, 255, 255}), size, size, 1)
+	if n := dots(white, white.Bounds()); n != 0 {
+		t.Errorf("pure white got %d dots", n)
+	}
+}
+
+func TestPageCurl(t *testing.T) {
+	const size = 30
+	src := noiseImage(size, size, 20)
+	assertSameImage(t, pageCurl(src, size, size, 0), src)
+
+	out := pageCurl(src, size, size, 0.5)
+	for y := 0; y < size; y++ {
+		for x := 0; x+y < size-2; x++ {
+			if rgbaAt(out, x, y) != src.RGBAAt(x, y) {
+				t.Fatalf("pixel (%d, %d) away from the corner changed", x, y)
+			}
+		}
+	}
+	if rgbaAt(out, size-1, size-1) == src.RGBAAt(size-1, size-1) {
+		t.Error("bottom right corner did not curl")
+	}
+}
+
+func TestChromeBanding(t *testing.T) {
+	const width = 256
+	out := chrome(rampImage(width, 4, 1), width, 4)
+
+	left, middle, right := rgbaAt(out, 0, 1).R, rgbaAt(out, width/2, 1).R, rgbaAt(out, width-1, 1).R
+	if left > 40 || middle < 230 || right > 40 {
+		t.Errorf("ramp shades to %d, %d, %d, want dark, light, dark", left, middle, right)
+	}
+}
+
+func TestPsychedelic(t *testing.T) {
+	const size, levels = 16, 4
+	src := noiseImage(size, size, 21)
+
+	for _, hueStep := range []float64{60, 0} {
+		out := psychedelic(src, size, size, levels, hueStep)
+		bands := make(map[int]color.RGBA)
+		for y := 0; y < size; y++ {
+			for x := 0; x < size; x++ {
+				band := min(int(luma(src.RGBAAt(x, y))/256*levels), levels-1)
+				got := rgbaAt(out, x, y)
+				if prev, ok := bands[band]; ok && prev != got {
+					t.Fatalf("hue step %g: band %d has both %v and %v", hueStep, band, prev, got)
+				}
+				bands[band] = got
+
+				want := uint8(math.Round(float64(band) / (levels - 1) * 255))
+				if hueStep == 0 && got != (color.RGBA{want, want, want, 255}) {
+					t.Fatalf("hue step 0: band %d is %v, want the gray level %d", band, got, want)
+				}
+			}
+		}
+	}
+}
+
+func TestTextRings(t *testing.T) {
+	const size = 100
+	red, blue := color.RGBA{255, 0, 0, 255}, color.RGBA{0, 0, 255, 255}
+	src := newTestImage(size, size, func(x, y int) color.RGBA {
+		if x < size/2 {
+			return red
+		}
+		return blue
+	})
+	out := textRings(src, size, size, "AB")
+
+	inked := 0
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			switch rgbaAt(out, x, y) {
+			case red, blue:
+				inked++
+			case color.RGBA{0, 0, 0, 255}:
+			default:
+				t.Fatalf("pixel (%d, %d) is not a source color or the background", x, y)
+			}
+		}
+	}
+	if inked == 0 {
+		t.Error("no characters drawn")
+	}
+	if rgbaAt(out, 0, 0) != (color.RGBA{0, 0, 0, 255}) {
+		t.Error("rings reach into the corner")
+	}
+}
+
+func TestScreenPrint(t *testing.T) {
+	const size = 20
+	c1, c2 := color.RGBA{200, 50, 50, 255}, color.RGBA{50, 50, 200, 255}
+	src := newTestImage(size, size, func(x, y int) color.RGBA {
+		if x >= 5 && x < 10 && y >= 5 && y < 10 {
+			return color.RGBA{0, 0, 0, 255}
+		}
+		return color.RGBA{255, 255, 255, 255}
+	})
+	both := color.RGBA{
+		uint8(255 * float64(c2.R) / 255 * float64(c1.R) / 255),
+		uint8(255 * float64(c2.G) / 255 * float64(c1.G) / 255),
+		uint8(255 * float64(c2.B) / 255 * float64(c1.B) / 255),
+		255,
+	}
+
+	for _, tc := range []struct {
+		offset int
+		at     image.Point
+		want   color.RGBA
+	}{
+		{0, image.Point{5, 5}, both},
+		{0, image.Point{9, 9}, both},
+		{0, image.Point{12, 12}, color.RGBA{255, 255, 255, 255}},
+		{3, image.Point{5, 5}, c1},
+		{3, image.Point{9, 9}, both},
+		{3, image.Point{12, 12}, c2},
+	} {
+		out := screenPrint(src, size, size, c1, c2, tc.offset)
+		if got := rgbaAt(out, tc.at.X, tc.at.Y); got != tc.want {
+			t.Errorf("offset %d: pixel %v is %v, want %v", tc.offset, tc.at, got, tc.want)
+		}
+	}
+}
+
+func TestRadialRainbow(t *testing.T) {
+	const size = 21
+	src := noiseImage(size, size, 22)
+	assertSameImage(t, radialRainbow(src, size, size, 0), src)
+
+	out := radialRainbow(uniformImage(size, size, color.RGBA{128, 128, 128, 255}), size, size, 0.5)
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			got := rgbaAt(out, x, y)
+			for _, p := range []image.Point{{size - 1 - x, y}, {x, size - 1 - y}, {y, x}} {
+				if other := rgbaAt(out, p.X, p.Y); other != got {
+					t.Fatalf("pixels (%d, %d) and %v are the same distance out but tinted %v and %v", x, y, p, got, other)
+				}
+			}
+		}
+	}
+}
+
+func TestBestQuadKaleido(t *testing.T) {
+	const size = 20
+	noise := noiseImage(size, size, 23)
+	src := newTestImage(size, size, func(x, y int) color.RGBA {
+		if x >= size/2 && y >= size/2 {
+			return noise.RGBAAt(x, y)
+		}
+		return color.RGBA{128, 128, 128, 255}
+	})
+	out := bestQuadKaleido(src, size, size)
+
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			srcX, srcY := size-1-min(x, size-1-x), size-1-min(y, size-1-y)
+			if got, want := rgbaAt(out, x, y), src.RGBAAt(srcX, srcY); got != want {
+				t.Fatalf("pixel (%d, %d) is %v, want %v mirrored from the colorful corner", x, y, got, want)
+			}
+		}
+	}
+}
+
+func TestRankFilter(t *testing.T) {
+	const size = 15
+	gray := color.RGBA{128, 128, 128, 255}
+	noisy := uniformImage(size, size, gray)
+	for i, p := range []image.Point{{3, 3}, {7, 3}, {11, 7}, {3, 11}, {7, 11}} {
+		if i%2 == 0 {
+			noisy.SetRGBA(p.X, p.Y, color.RGBA{255, 255, 255, 255})
+		} else {
+			noisy.SetRGBA(p.X, p.Y, color.RGBA{0, 0, 0, 255})
+		}
+	}
+	assertSameImage(t, rankFilter(noisy, size, size, 1, 0.5), uniformImage(size, size, gray))
+
+	// The brightest neighbour spreads a single white pixel to a 3x3 block
+	dot := uniformImage(size, size, color.RGBA{0, 0, 0, 255})
+	dot.SetRGBA(7, 7, color.RGBA{255, 255, 255, 255})
+	out := rankFilter(dot, size, size, 1, 1)
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			inBlock := x >= 6 && x <= 8 && y >= 6 && y <= 8
+			if white := rgbaAt(out, x, y).R == 255; white != inBlock {
+				t.Fatalf("pixel (%d, %d) white is %v, want %v", x, y, white, inBlock)
+			}
+		}
+	}
+}
+
+func TestDiagonalStripes(t *testing.T) {
+	const width, height, stripe = 20, 15, 5
+	src := noiseImage(width, height, 24)
+	assertSameImage(t, diagonalStripes(src, width, height, width+height), src)
+
+	// Even bands are untouched and odd ones are reflected across the middle of the band
+	out := diagonalStripes(src, width, height, stripe)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			band := (x + y) / stripe
+			srcX := x
+			if band%2 == 1 {
+				mirrored := 2*band*stripe + stripe - 1 - (x + y)
+				srcX = max(0, min(mirrored-y, width-1))
+			}
+			if got, want := rgbaAt(out, x, y), src.RGBAAt(srcX, y); got != want {
+				t.Fatalf("pixel (%d, %d) in band %d is %v, want %v", x, y, band, got, want)
+			}
+		}
+	}
+}
+
+func TestComicPanel(t *testing.T) {
+	const size = 120
+	src := uniformImage(size, size, color.RGBA{100, 150, 200, 255})
+	out := comicPanel(src, size, size, "WOW!")
+	halftone := cmykHalftone(src, size, size, max(4, size/60))
+
+	// "WOW!" is 28x13 at scale 1, centered in the bubble around (29, 20)
+	black, white := 0, 0
+	for y := 13; y < 26; y++ {
+		for x := 15; x < 43; x++ {
+			switch rgbaAt(out, x, y) {
+			case color.RGBA{0, 0, 0, 255}:
+				black++
+			case color.RGBA{255, 255, 255, 255}:
+				white++
+			default:
+				t.Fatalf("pixel (%d, %d) in the bubble is not black or white", x, y)
+			}
+		}
+	}
+	if black == 0 || white <= black {
+		t.Errorf("bubble has %d black and %d white pixels, want dark text on light", black, white)
+	}
+	for y := size / 2; y < size; y++ {
+		for x := size / 2; x < size; x++ {
+			if rgbaAt(out, x, y) != rgbaAt(halftone, x, y) {
+				t.Fatalf("pixel (%d, %d) outside the bubble is not the halftone", x, y)
+			}
+		}
+	}
+}
+
+func TestGlassBlocks(t *testing.T) {
+	const size, block = 64, 8
+	// Every pixel stores its own position so the sampled spot can be read back
+	src := newTestImage(size, size, func(x, y int) color.RGBA {
+		return color.RGBA{uint8(x * 3), uint8(y * 3), 0, 255}
+	})
+	interior := func(x, y int) bool {
+		return x%block != 0 && x%block != block-1 && y%block != 0 && y%block != block-1
+	}
+
+	clean := glassBlocks(src, size, size, block, 0)
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			if interior(x, y) && rgbaAt(clean, x, y) != src.RGBAAt(x, y) {
+				t.Fatalf("pixel (%d, %d) moved without refraction", x, y)
+			}
+		}
+	}
+
+	// Skip the outer tiles, their samples can get clamped at the image edge
+	out := glassBlocks(src, size, size, block, 0.5)
+	for ty := 1; ty < size/block-1; ty++ {
+		for tx := 1; tx < size/block-1; tx++ {
+			var want image.Point
+			first := true
+			for y := ty * block; y < (ty+1)*block; y++ {
+				for x := tx * block; x < (tx+1)*block; x++ {
+					if !interior(x, y) {
+						continue
+					}
+					c := rgbaAt(out, x, y)
+					offset := image.Point{int(c.R)/3 - x, int(c.G)/3 - y}
+					if first {
+						want, first = offset, false
+					} else if offset != want {
+						t.Fatalf("tile (%d, %d) samples with offset %v and %v", tx, ty, want, offset)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestDoubleVision(t *testing.T) {
+	const size = 10
+	src := noiseImage(size, size, 25)
+	assertSameImage(t, doubleVision(src, size, size, 3, 2, 0), src)
+
+	dot := uniformImage(size, size, color.RGBA{0, 0, 0, 255})
+	dot.SetRGBA(5, 5, color.RGBA{255, 255, 255, 255})
+	out := doubleVision(dot, size, size, 3, 2, 0.5)
+	half := color.RGBA{128, 128, 128, 255}
+	if got := rgbaAt(out, 8, 7); got != half {
+		t.Errorf("ghost at (8, 7) is %v, want %v", got, half)
+	}
+	if got := rgbaAt(out, 5, 5); got != half {
+		t.Errorf("original at (5, 5) is %v, want %v", got, half)
+	}
+	if got := rgbaAt(out, 0, 0); got != (color.RGBA{0, 0, 0, 255}) {
+		t.Errorf("pixel (0, 0) is %v, want black", got)
+	}
+}
+
+func TestASCIIColorTwoCharRamp(t *testing.T) {
+	const size, cell, ramp = 32, 16, ".#"
+	dark, light := color.RGBA{60, 10, 10, 255}, color.RGBA{250, 240, 200, 255}
+	src := newTestImage(size, size, func(x, y int) color.RGBA {
+		if (x/cell+y/cell)%2 == 0 {
+			return dark
+		}
+		return light
+	})
+	out := asciiColor(src, size, size, cell, ramp)
+
+	// What each glyph of the ramp looks like stretched over a cell
+	mask := func(c rune) [cell][cell]bool {
+		var m [cell][cell]bool
+		glyph := textBitmap(string(c))
+		gw, gh := glyph.Bounds().Dx(), glyph.Bounds().Dy()
+		for y := 0; y < cell; y++ {
+			for x := 0; x < cell; x++ {
+				m[y][x] = glyph.AlphaAt(x*gw/cell, y*gh/cell).A > 127
+			}
+		}
+		return m
+	}
+	glyphs := [][cell][cell]bool{mask('.'), mask('#')}
+
+	for by := 0; by < size; by += cell {
+		for bx := 0; bx < size; bx += cell {
+			mean := src.RGBAAt(bx, by)
+			var got [cell][cell]bool
+			for y := 0; y < cell; y++ {
+				for x := 0; x < cell; x++ {
+					c := rgbaAt(out, bx+x, by+y)
+					if c == mean {
+						got[y][x] = true
+					} else if c != (color.RGBA{0, 0, 0, 255}) {
+						t.Fatalf("pixel (%d, %d) is %v, want the cell mean %v or black", bx+x, by+y, c, mean)
+					}
+				}
+			}
+			if got != glyphs[0] && got != glyphs[1] {
+				t.Fatalf("cell at (%d, %d) is not one of the ramp glyphs", bx, by)
+			}
+		}
+	}
+}
+
+func TestPrism(t *testing.T) {
+	const size, spread = 20, 6
+	src := noiseImage(size, size, 26)
+	assertSameImage(t, prism(src, size, size, 0), src)
+
+	out := prism(src, size, size, spread)
+	for y := spread; y < size; y++ {
+		for x := spread; x < size; x++ {
+			got := rgbaAt(out, x, y)
+			want := color.RGBA{
+				src.RGBAAt(x, y).R,
+				src.RGBAAt(x-spread/2, y-spread/2).G,
+				src.RGBAAt(x-spread, y-spread).B,
+				255,
+			}
+			if got != want {
+				t.Fatalf("pixel (%d, %d) is %v, want %v", x, y, got, want)
+			}
+		}
+	}
+}
+
+func TestMandalaSymmetry(t *testing.T) {
+	const size, petals = 81, 6
+	src := newTestImage(size, size, func(x, y int) color.RGBA {
+		return color.RGBA{
+			uint8(128 + 100*math.Sin(float64(x)/7)),
+			uint8(128 + 100*math.Cos(float64(y)/9)),
+			uint8(128 + 100*math.Sin(float64(x+y)/11)),
+			255,
+		}
+	})
+	out := mandala(src, size, size, petals)
+
+	// Compare every pixel with the one a petal further round, sampled between pixels
+	total, count := 0.0, 0
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			if math.Hypot(float64(x-40), float64(y-40)) > 35 {
+				continue
+			}
+			rx, ry := rotatePoint(float64(x), float64(y), 40, 40, 360/petals)
+			a, b := rgbaAt(out, x, y), bilinear(out, size, size, rx, ry)
+			total += math.Abs(float64(a.R)-float64(b.R)) + math.Abs(float64(a.G)-float64(b.G)) + math.Abs(float64(a.B)-float64(b.B))
+			count++
+		}
+	}
+	if mean := total / float64(count*3); mean > 4 {
+		t.Errorf("mean difference to the next petal is %.1f, want near 0", mean)
+	}
+}
+
+func TestVectorWire(t *testing.T) {
+	const size = 40
+	black := color.RGBA{0, 0, 0, 255}
+	flat := vectorWire(uniformImage(size, size, color.RGBA{90, 90, 90, 255}), size, size, 6)
+	assertSameImage(t, flat, uniformImage(size, size, black))
+
+	disc := newTestImage(size, size, func(x, y int) color.RGBA {
+		if math.Hypot(float64(x-20), float64(y-20)) < 10 {
+			return color.RGBA{255, 255, 255, 255}
+		}
+		return black
+	})
+	out := vectorWire(disc, size, size, 6)
+	lines := 0
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			if rgbaAt(out, x, y) == black {
+				continue
+			}
+			lines++
+			if r := math.Hypot(float64(x-20), float64(y-20)); math.Abs(r-10) > 2 {
+				t.Fatalf("line pixel (%d, %d) is away from the edge of the disc", x, y)
+			}
+		}
+	}
+	if lines == 0 || lines > size*size/4 {
+		t.Errorf("got %d line pixels, want a sparse outline", lines)
+	}
+}
+
+func TestOrder8(t *testing.T) {
+	const size = 16
+	out := order8(noiseImage(size, size, 27), size, size)
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			c := rgbaAt(out, x, y)
+			for _, v := range []uint8{c.R, c.G, c.B} {
+				if v != 0 && v != 255 {
+					t.Fatalf("pixel (%d, %d) is %v, not one of the eight colors", x, y, c)
+				}
+			}
+		}
+	}
+
+	// Mid gray turns into the Bayer crosshatch, half the cells of every 4x4 tile lit
+	gray := order8(uniformImage(size, size, color.RGBA{128, 128, 128, 255}), size, size)
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			if rgbaAt(gray, x, y) != rgbaAt(gray, x%4, y%4) {
+				t.Fatalf("pixel (%d, %d) breaks the 4x4 pattern", x, y)
+			}
+		}
+	}
+	lit := 0
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 4; x++ {
+			if rgbaAt(gray, x, y).R == 255 {
+				lit++
+			}
+		}
+	}
+	if lit != 8 {
+		t.Errorf("%d of 16 pixels lit in the pattern, want 8", lit)
+	}
+}
+
+func TestDiagonalFoldSymmetry(t *testing.T) {
+	const size = 15
+	out := diagonalFold(noiseImage(size, size, 28), size, size)
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			if a, b := rgbaAt(out, x, y), rgbaAt(out, y, x); a != b {
+				t.Fatalf("pixel (%d, %d) is %v but (%d, %d) is %v", x, y, a, y, x, b)
+			}
+		}
+	}
+}
+
+func TestStainedGlass(t *testing.T) {
+	const size, cells, seed = 40, 10, 2
+	out := stainedGlass(noiseImage(size, size, 29), size, size, cells, seed)
+	regions, _ := voronoiRegions(size, size, cells, seed)
+	lead := color.RGBA{25, 20, 15, 255}
+
+	fills := make(map[int]color.RGBA)
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			region := regions[y*size+x]
+			border := false
+			for _, d := range []image.Point{{1, 0}, {-1, 0}, {0, 1}, {0, -1}} {
+				nx, ny := x+d.X, y+d.Y
+				if nx >= 0 && ny >= 0 && nx < size && ny < size && regions[ny*size+nx] != region {
+					border = true
+				}
+			}
+			got := rgbaAt(out, x, y)
+			if border {
+				if got != lead {
+					t.Fatalf("border pixel (%d, %d) is %v, want lead", x, y, got)
+				}
+				continue
+			}
+			if fill, ok := fills[region]; ok && fill != got {
+				t.Fatalf("region %d has both %v and %v inside", region, fill, got)
+			}
+			fills[region] = got
+		}
+	}
+}
+
+func TestRadialTrailFromBrightSpot(t *testing.T) {
+	const size = 30
+	src := uniformImage(size, size, color.RGBA{40, 40, 40, 255})
+	for y := 6; y <= 10; y++ {
+		for x := 6; x <= 10; x++ {
+			src.SetRGBA(x, y, color.RGBA{255, 255, 255, 255})
+		}
+	}
+	out := radialTrail(src, size, size, 16)
+
+	// The first pixel of the spot is the brightest, the blur has to be centered there
+	assertSameImage(t, out, zoomBlur(src, size, size, 6, 6, 0.3, 16))
+	if !differentImages(out, zoomBlur(src, size, size, float64(size-1)/2, float64(size-1)/2, 0.3, 16)) {
+		t.Error("trail is the same as one from the image center")
+	}
+	if got := rgbaAt(out, 12, 12); got.R <= 40 {
+		t.Errorf("pixel (12, 12) past the spot is %v, want a ray through it", got)
+	}
+}
+
+func TestEqualizerBars(t *testing.T) {
+	const width, height, bars = 40, 40, 4
+	levels := []uint8{50, 100, 150, 200}
+	src := newTestImage(width, height, func(x, y int) color.RGBA {
+		v := levels[x/(width/bars)]
+		return color.RGBA{v, v / 2, v / 3, 255}
+	})
+	out := equalizerBars(src, width, height, bars)
+
+	prevHeight := -1
+	for i, v := range levels {
+		x := i*width/bars + 1
+		want := color.RGBA{v, v / 2, v / 3, 255}
+		barHeight := 0
+		for y := 0; y < height; y++ {
+			switch rgbaAt(out, x, y) {
+			case want:
+				barHeight++
+			case color.RGBA{0, 0, 0, 255}:
+			default:
+				t.Fatalf("bar %d has a pixel that is not its column average %v", i, want)
+			}
+		}
+		if barHeight <= prevHeight {
+			t.Errorf("bar %d is %d tall, want taller than the darker bar before it", i, barHeight)
+		}
+		prevHeight = barHeight
+	}
+}
+
+func TestSpotlightRect(t *testing.T) {
+	const size = 60
+	red := color.RGBA{200, 50, 50, 255}
+	out := spotlightRect(uniformImage(size, size, red), size, size, 20, 20, 40, 40)
+
+	if got := rgbaAt(out, 30, 30); got != red {
+		t.Errorf("pixel inside the spotlight is %v, want %v", got, red)
+	}
+	if got := rgbaAt(out, 0, 0); !isGray(got) {
+		t.Errorf("pixel far outside the spotlight is %v, want gray", got)
+	}
+}
+
+func TestSpotlightRectOutsideImage(t *testing.T) {
+	const width, height = 40, 30
+	src := noiseImage(width, height, 48)
+	for _, rect := range []image.Rectangle{
+		image.Rect(30, 10, 50, 20), // Past the right edge
+		image.Rect(-5, -5, 10, 10), // Before the top left corner
+		image.Rect(60, 40, 80, 50), // Nowhere near the image
+		image.Rect(10, 10, 10, 20), // No width
+	} {
+		if _, err := spotlightBounds(width, height, rect.Min.X, rect.Min.Y, rect.Max.X, rect.Max.Y); err == nil {
+			t.Errorf("spotlight %v was accepted", rect)
+		}
+		assertSameImage(t, spotlightRect(src, width, height, rect.Min.X, rect.Min.Y, rect.Max.X, rect.Max.Y), src)
+	}
+
+	// Touching the edges is still inside
+	if _, err := spotlightBounds(width, height, 0, 0, width, height); err != nil {
+		t.Errorf("the whole image was rejected: %v", err)
+	}
+}
+
+func TestRankScramble(t *testing.T) {
+	const width, height = 13, 9
+	src := noiseImage(width, height, 30)
+	out := rankScramble(src, width, height)
+
+	count := make(map[color.RGBA]int)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			count[src.RGBAAt(x, y)]++
+			count[rgbaAt(out, x, y)]--
+		}
+	}
+	for col, n := range count {
+		if n != 0 {
+			t.Fatalf("%v appears %d times too often in the source", col, n)
+		}
+	}
+
+	prev := -1.0
+	for _, p := range hilbertPoints(width, height) {
+		l := luma(rgbaAt(out, p.X, p.Y))
+		if l < prev {
+			t.Fatalf("luma drops at %v along the Hilbert curve", p)
+		}
+		prev = l
+	}
+}
+
+func TestConvertImageHorizontalMirrorsEdges(t *testing.T) {
+	const width, height = 9, 3
+	// Blue rises across the image so every column can be told apart
+	src := newTestImage(width, height, func(x, y int) color.RGBA {
+		return color.RGBA{uint8(10 + y), 100, uint8(20 + x*25), 255}
+	})
+
+	out := convertImageHorizontal(src, width, height, 1, 1, 1)
+	for y := 0; y < height; y++ {
+		for _, x := range []int{0, width - 1} {
+			got, own, mirrored := rgbaAt(out, x, y), rgbaAt(src, x, y), rgbaAt(src, width-1-x, y)
+			if want := (color.RGBA{mirrored.B, own.G, own.R, 255}); got != want {
+				t.Errorf("pixel (%d, %d) is %v, want %v", x, y, got, want)
+			}
+		}
+	}
+}
+
+// Passes pixels through from the image it wraps, remembering every point asked for
+type recordingImage struct {
+	image.Image
+	mu   sync.Mutex
+	read []image.Point
+}
+
+func (r *recordingImage) At(x, y int) color.Color {
+	r.mu.Lock()
+	r.read = append(r.read, image.Pt(x, y))
+	r.mu.Unlock()
+	return r.Image.At(x, y)
+}
+
+func TestSickTwistStaysInBounds(t *testing.T) {
+	for _, size := range []image.Point{{7, 5}, {8, 6}, {1, 1}} {
+		rec := &recordingImage{Image: noiseImage(size.X, size.Y, 43)}
+		sickTwist(rec, size.X, size.Y)
+		if len(rec.read) == 0 {
+			t.Fatalf("%v: sickTwist read no pixels", size)
+		}
+		for _, p := range rec.read {
+			if !p.In(rec.Bounds()) {
+				t.Fatalf("%v: read pixel %v outside %v", size, p, rec.Bounds())
+			}
+		}
+	}
+}
+
+func TestKmeansPosterize(t *testing.T) {
+	const size, k = 24, 5
+	src := noiseImage(size, size, 32)
+	out := kmeansPosterize(src, size, size, k, 3)
+
+	colors := make(map[color.RGBA]bool)
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			colors[rgbaAt(out, x, y)] = true
+		}
+	}
+	if len(colors) > k {
+		t.Errorf("poster has %d colors, want at most %d", len(colors), k)
+	}
+	assertSameImage(t, kmeansPosterize(src, size, size, k, 3), out)
+}
+
+func TestFunhouse(t *testing.T) {
+	const width, height = 16, 12
+	src := noiseImage(width, height, 33)
+	mirror := newTestImage(width, height, func(x, y int) color.RGBA { return rgbaAt(src, width-1-x, y) })
+
+	// No sway leaves a plain mirror image
+	assertSameImage(t, funhouse(src, width, height, 0, 2), mirror)
+
+	const amplitude, frequency = 5.0, 1.0
+	out := funhouse(src, width, height, amplitude, frequency)
+	for y := 0; y < height; y++ {
+		offset := int(amplitude * math.Sin(2*math.Pi*frequency*float64(y)/height))
+		for x := 0; x < width; x++ {
+			srcX := ((x+offset)%width + width) % width
+			if got, want := rgbaAt(out, x, y), rgbaAt(mirror, srcX, y); got != want {
+				t.Fatalf("pixel (%d, %d) is %v, want %v from mirrored column %d", x, y, got, want, srcX)
+			}
+		}
+	}
+}
+
+func TestGrayscale(t *testing.T) {
+	const width, height = 10, 8
+	noise := noiseImage(width, height, 40)
+	// Premultiplied colors with alpha going down the rows
+	src := newTestImage(width, height, func(x, y int) color.RGBA {
+		c, a := rgbaAt(noise, x, y), uint32(255-y*30)
+		return color.RGBA{uint8(uint32(c.R) * a / 255), uint8(uint32(c.G) * a / 255), uint8(uint32(c.B) * a / 255), uint8(a)}
+	})
+
+	out := grayscale(src, width, height)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			got := rgbaAt(out, x, y)
+			if !isGray(got) {
+				t.Fatalf("pixel (%d, %d) is %v, want R, G and B equal", x, y, got)
+			}
+			if want := src.RGBAAt(x, y).A; got.A != want {
+				t.Fatalf("pixel (%d, %d) has alpha %d, want %d", x, y, got.A, want)
+			}
+		}
+	}
+}
+
+func TestEdgeDetect(t *testing.T) {
+	const width, height, edge = 12, 8, 6
+	src := newTestImage(width, height, func(x, y int) color.RGBA {
+		if x < edge {
+			return color.RGBA{0, 0, 0, 255}
+		}
+		return color.RGBA{255, 255, 255, 255}
+	})
+
+	out := edgeDetect(src, width, height)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			got := rgbaAt(out, x, y).R
+			switch {
+			case x == edge-1 || x == edge:
+				if got < 200 {
+					t.Errorf("pixel (%d, %d) on the edge is %d, want a strong response", x, y, got)
+				}
+			case x < edge-2 || x > edge+1:
+				if got > 5 {
+					t.Errorf("pixel (%d, %d) in a flat area is %d, want about 0", x, y, got)
+				}
+			}
+		}
+	}
+}
+
+func TestPixelate(t *testing.T) {
+	// 4 doesn't divide 10 or 7, leaving short blocks along the right and bottom
+	const width, height, block = 10, 7, 4
+	src := noiseImage(width, height, 41)
+	out := pixelate(src, width, height, block)
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			corner := rgbaAt(out, x/block*block, y/block*block)
+			if got := rgbaAt(out, x, y); got != corner {
+				t.Fatalf("pixel (%d, %d) is %v, want %v like the rest of its block", x, y, got, corner)
+			}
+		}
+	}
+	if rgbaAt(out, 0, 0) == rgbaAt(out, block, 0) {
+		t.Error("neighboring blocks of noise got the same color")
+	}
+
+	// The short corner block averages only the pixels it covers
+	corner := image.Rect(8, 4, width, height)
+	src = newTestImage(width, height, func(x, y int) color.RGBA {
+		if image.Pt(x, y).In(corner) {
+			return color.RGBA{200, 100, 50, 255}
+		}
+		return color.RGBA{0, 0, 0, 255}
+	})
+	if got, want := rgbaAt(pixelate(src, width, height, block), width-1, height-1), (color.RGBA{200, 100, 50, 255}); got != want {
+		t.Errorf("corner block is %v, want %v", got, want)
+	}
+}
+
+func TestRotateFullTurn(t *testing.T) {
+	const width, height = 11, 8
+	src := noiseImage(width, height, 42)
+	for _, degrees := range []float64{0, 360, -360} {
+		if d := maxDiff(rotate(src, width, height, degrees, color.RGBA{}), src); d > 1 {
+			t.Errorf("rotating %g degrees is off from the original by %d", degrees, d)
+		}
+	}
+}
+
+func TestFlipsUndoThemselves(t *testing.T) {
+	const width, height = 9, 6
+	src := noiseImage(width, height, 39)
+	for _, flip := range []func(image.Image, int, int) draw.Image{flipHorizontal, flipVertical} {
+		once := flip(src, width, height)
+		if !differentImages(once, src) {
+			t.Error("flipping left the image as it was")
+		}
+		assertSameImage(t, flip(once, width, height), src)
+	}
+	if got, want := rgbaAt(flipHorizontal(src, width, height), 0, 2), rgbaAt(src, width-1, 2); got != want {
+		t.Errorf("horizontal flip starts the row with %v, want %v", got, want)
+	}
+	if got, want := rgbaAt(flipVertical(src, width, height), 3, 0), rgbaAt(src, 3, height-1); got != want {
+		t.Errorf("vertical flip starts the column with %v, want %v", got, want)
+	}
+}
+
+func TestSepia(t *testing.T) {
+	tests := []struct {
+		name string
+		in   color.RGBA
+		want color.RGBA
+	}{
+		{"opaque", color.RGBA{100, 150, 200, 255}, color.RGBA{192, 171, 134, 255}},
+		{"white clamps", color.RGBA{255, 255, 255, 255}, color.RGBA{255, 255, 239, 255}},
+		// Premultiplied (100, 150, 200) at half alpha, toned like the opaque pixel
+		{"half transparent", color.RGBA{50, 75, 100, 128}, color.RGBA{96, 86, 67, 128}},
+		{"transparent", color.RGBA{}, color.RGBA{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := rgbaAt(sepia(uniformImage(2, 2, tt.in), 2, 2), 1, 1)
+			if colorDiff(got, tt.want) > 1 || got.A != tt.want.A {
+				t.Errorf("sepia of %v is %v, want about %v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+// Every static transform resolves by name and renders a frame the size of the input
+func TestExtrasRender(t *testing.T) {
+	src := noiseImage(48, 32, 31)
+	for _, tr := range extras {
+		got, ok := Lookup(tr.Name())
+		if !ok {
+			t.Errorf("%s is not registered", tr.Name())
+			continue
+		}
+		if out := got.Apply(src, 48, 32); out.Bounds() != src.Bounds() {
+			t.Errorf("%s gave bounds %v, want %v", tr.Name(), out.Bounds(), src.Bounds())
+		}
+	}
+}
+
+// The full square convolution that gaussianBlur avoids, kept to check it against
+func naiveGaussianBlur(img image.Image, width, height int, radius float64) *image.RGBA {
+	kernel := gaussianKernel(radius / 3)
+	r := len(kernel) / 2
+	newImg := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			var sum [4]float64
+			for ky, wy := range kernel {
+				for kx, wx := range kernel {
+					sx := max(0, min(x+kx-r, width-1))
+					sy := max(0, min(y+ky-r, height-1))
+					c := rgbaAt(img, sx, sy)
+					w := wx * wy
+					sum[0] += float64(c.R) * w
+					sum[1] += float64(c.G) * w
+					sum[2] += float64(c.B) * w
+					sum[3] += float64(c.A) * w
+				}
+			}
+			newImg.SetRGBA(x, y, color.RGBA{
+				uint8(clamp(int(math.Round(sum[0])))),
+				uint8(clamp(int(math.Round(sum[1])))),
+				uint8(clamp(int(math.Round(sum[2])))),
+				uint8(clamp(int(math.Round(sum[3])))),
+			})
+		}
+	}
+	return newImg
+}
+
+// Only the rounding between the two passes tells them apart
+func TestGaussianBlurMatchesNaive(t *testing.T) {
+	const size = 24
+	src := noiseImage(size, size, 37)
+	if d := maxDiff(gaussianBlur(src, size, size, 6), naiveGaussianBlur(src, size, size, 6)); d > 1 {
+		t.Errorf("separable blur is off from the full convolution by %d", d)
+	}
+}
+
+func BenchmarkGaussianBlurSeparable(b *testing.B) {
+	src := noiseImage(128, 128, 38)
+	for i := 0; i < b.N; i++ {
+		gaussianBlur(src, 128, 128, 6)
+	}
+}
+
+func BenchmarkGaussianBlurNaive(b *testing.B) {
+	src := noiseImage(128, 128, 38)
+	for i := 0; i < b.N; i++ {
+		naiveGaussianBlur(src, 128, 128, 6)
+	}
+}
@@ -0,0 +1,512 @@
+// Package wackygif turns a single image into a GIF where every frame is a
+// different wacky transformation of it
+package wackygif
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/draw"
+	"image/gif"
+	"math"
+	"math/rand"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+const DefaultDelay = 10 // Default delay in 100th of a second
+
+// Settings for GenerateGIF. The zero value gives a looping GIF with the default
+// delay, the Plan9 palette and the frames shuffled with seed 0
+type Options struct {
+	Delay     int           // In 100th of a second, 0 means DefaultDelay
+	LoopCount int           // Same meaning as gif.GIF.LoopCount
+	Seed      int64         // Seed for the frame order, the same seed gives the same GIF
+	NoShuffle bool          // Keep the frames in the order the transformations are declared
+	Echo      float64       // How much of the previous frame stays visible, 0 for none
+	Palette   color.Palette // Colors used in the GIF, nil means palette.Plan9
+
+	// Number of frames in the GIF, taking the first transforms when there are fewer frames
+	// and cycling through them again when there are more. 0 gives one frame per transform
+	Frames int
+
+	// Registered names of the transforms to use, in the order of the frames. Picked
+	// transforms are never shuffled. Empty means the default set
+	Transforms []string
+}
+
+// Renders every transformation of img as a frame and puts them together as a GIF
+func GenerateGIF(img image.Image, opts Options) (*gif.GIF, error) {
+	if img == nil {
+		return nil, errors.New("no image to transform")
+	}
+	if opts.Delay == 0 {
+		opts.Delay = DefaultDelay
+	}
+	if opts.Delay < 1 {
+		return nil, fmt.Errorf("invalid delay %d, must be at least 1", opts.Delay)
+	}
+	if opts.Echo < 0 || opts.Echo >= 1 {
+		return nil, fmt.Errorf("invalid echo %g, must be at least 0 and less than 1", opts.Echo)
+	}
+	if opts.LoopCount < -1 {
+		return nil, fmt.Errorf("invalid loop count %d, must be -1, 0 or a positive number of extra loops", opts.LoopCount)
+	}
+	if opts.Frames < 0 {
+		return nil, fmt.Errorf("invalid frame count %d, must be at least 1", opts.Frames)
+	}
+	pal := opts.Palette
+	if pal == nil {
+		pal = palette.Plan9
+	}
+	if len(pal) == 0 {
+		return nil, errors.New("palette has no colors")
+	}
+
+	// The transformations all work in coordinates starting at (0, 0)
+	img = translateToOrigin(img)
+
+	width := img.Bounds().Dx()
+	height := img.Bounds().Dy()
+
+	transformations := defaultTransformations()
+	if len(opts.Transforms) > 0 {
+		transformations = transformations[:0]
+		for _, name := range opts.Transforms {
+			t, ok := Lookup(name)
+			if !ok {
+				return nil, fmt.Errorf("unknown transform %q, valid transforms are: %s", name, strings.Join(ListTransforms(), ", "))
+			}
+			transformations = append(transformations, t)
+		}
+	}
+
+	// Shuffle the transformations, unless they should stay in the order they are declared or given
+	if !opts.NoShuffle && len(opts.Transforms) == 0 {
+		shuffle(transformations, rand.New(rand.NewSource(opts.Seed)))
+	}
+	// Frame i shows transformations[order[i]], cycling through the list when there are
+	// more frames than transforms
+	order := make([]int, len(transformations))
+	if opts.Frames > 0 {
+		order = make([]int, opts.Frames)
+	}
+	for i := range order {
+		order[i] = i % len(transformations)
+	}
+
+	frames := make([]draw.Image, len(order))
+	images := make([]*image.Paletted, len(order))
+	delays := make([]int, len(order))
+	static := make([]draw.Image, len(transformations))
+	var wg sync.WaitGroup
+
+	// Runs the work on its own goroutine, but never more of them at once than there are CPUs
+	sem := make(chan struct{}, runtime.NumCPU())
+	run := func(work func()) {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer func() { <-sem; wg.Done() }()
+			work()
+		}()
+	}
+
+	// A static transform looks the same in every frame so it is rendered once, by its
+	// place in the list, and shared by all the frames showing it. Animations are told
+	// which frame they are rendering out of how many, so they render every frame.
+	// Every goroutine writes to its own index so the frames keep their order
+	for i, j := range order {
+		transform := transformations[j]
+		if a, ok := transform.(Animated); ok {
+			run(func() { frames[i] = a.ApplyFrame(img, width, height, i, len(frames)) })
+		} else if i == j {
+			run(func() { static[j] = transform.Apply(img, width, height) })
+		}
+	}
+
+	wg.Wait() // Wait for all the goroutines
+
+	for i, j := range order {
+		if frames[i] == nil {
+			frames[i] = static[j]
+		}
+	}
+
+	// Let every frame trail into the next one
+	if opts.Echo > 0 {
+		echoFrames(frames, opts.Echo)
+	}
+
+	// Convert them to paletted for the gif format
+	for i, frame := range frames {
+		run(func() {
+			images[i] = convertToPaletted(frame, pal)
+			delays[i] = opts.Delay
+		})
+	}
+
+	wg.Wait()
+
+	return &gif.GIF{
+		Image:     images,
+		Delay:     delays,
+		LoopCount: opts.LoopCount,
+	}, nil
+}
+
+// The built in transformations in the order they make up the frames by default
+var builtins = []Transform{
+	describe(NewTransform("swap", func(img image.Image, width, height int) draw.Image {
+		return convertImageHorizontal(img, width, height, 1, 1, 1)
+	}), "swaps red and blue, taking the new red from the mirrored image", "channels=1,1,1"),
+	describe(NewTransform("swap-chain", func(img image.Image, width, height int) draw.Image {
+		newImg := convertImageHorizontal(img, width, height, 0, 1, 1)
+		newImg = convertImageHorizontal(newImg, width, height, 1, 1, 0)
+		newImg = convertImageHorizontal(newImg, width, height, 1, 1, 1)
+		return newImg
+	}), "swap three times, dropping red and then blue along the way", "channels=0,1,1 1,1,0 1,1,1"),
+	describe(NewTransform("vertical", convertImageVertical), "mixes the channels with the upside down image"),
+	describe(NewTransform("bright", func(img image.Image, width, height int) draw.Image {
+		return adjustBrightness(img, width, height, 4)
+	}), "multiplies the brightness of every channel", "factor=4"),
+	describe(NewTransform("wave", func(img image.Image, width, height int) draw.Image {
+		return waveImage(img, width, height, 20, 20)
+	}), "shifts the rows sideways along a sine wave", "amplitude=20", "frequency=20"),
+	describe(NewTransform("swap-no-green", func(img image.Image, width, height int) draw.Image {
+		return convertImageHorizontal(img, width, height, 1, 0, 1)
+	}), "swap with the green channel left out", "channels=1,0,1"),
+	describe(NewTransform("swap-no-red", func(img image.Image, width, height int) draw.Image {
+		return convertImageHorizontal(img, width, height, 0, 1, 1)
+	}), "swap with the red channel left out", "channels=0,1,1"),
+	describe(NewTransform("kaleidoscope-merge", func(img image.Image, width, height int) draw.Image {
+		newImg := kaleidoscopeImage(img, width, height)
+		newImg = mergeImages(img, newImg)
+		return newImg
+	}), "kaleidoscope with the green of the original image"),
+	describe(NewTransform("swap-triple", func(img image.Image, width, height int) draw.Image {
+		newImg := convertImageHorizontal(img, width, height, 1, 1, 1)
+		newImg = convertImageHorizontal(newImg, width, height, 1, 1, 1)
+		newImg = convertImageHorizontal(newImg, width, height, 1, 1, 1)
+		return newImg
+	}), "swap applied three times in a row", "channels=1,1,1"),
+	describe(NewTransform("wave-merge", func(img image.Image, width, height int) draw.Image {
+		newImg := waveImage(img, width, height, 100, 20)
+		newImg = mergeImages(img, newImg)
+		return newImg
+	}), "big wave with the green of the original image", "amplitude=100", "frequency=20"),
+	describe(NewTransform("kaleidoscope", kaleidoscopeImage), "mirrors the top left quarter into the other three"),
+	describe(NewTransform("strong", strong), "recolors every pixel based on its strongest channel"),
+	describe(NewTransform("sick-twist", sickTwist), "checkerboard of the image and its upside down mirror"),
+	describe(NewTransform("sick-twist-double", func(img image.Image, width, height int) draw.Image {
+
+		newImg := sickTwist(img, width, height)
+		newImg = convertImageHorizontal(newImg, width, height, 1, 1, 1)
+		newImg = convertImageHorizontal(newImg, width, height, 1, 1, 1)
+		return newImg
+	}), "sick twist followed by two swaps"),
+	describe(NewTransform("sick-twist-swap", func(img image.Image, width, height int) draw.Image {
+
+		newImg := sickTwist(img, width, height)
+		newImg = convertImageHorizontal(newImg, width, height, 1, 1, 1)
+		return newImg
+	}), "sick twist followed by a swap"),
+	describe(NewTransform("grayscale", grayscale), "black and white from the luminance of every pixel"),
+}
+
+// Built in transformations that can be picked by name but are left out of the default set
+var extras = []Transform{
+	describe(NewTransform("shape-mask", func(img image.Image, width, height int) draw.Image {
+		return shapeMask(img, width, height, "heart")
+	}), "keeps only the part of the image inside a shape", "shape=heart"),
+	describe(NewTransform("seam-squish", func(img image.Image, width, height int) draw.Image {
+		return seamSquish(img, width, height, width*2/3)
+	}), "carves out the dullest seams and stretches the rest back", "target=2/3 width"),
+	describe(NewTransform("led-wall", func(img image.Image, width, height int) draw.Image {
+		return ledWall(img, width, height, 12, 2)
+	}), "grid of glowing cells with dark gaps between them", "cell=12", "gap=2"),
+	describe(NewTransform("frosted-glass", func(img image.Image, width, height int) draw.Image {
+		return frostedGlass(img, width, height, 4, 1)
+	}), "scatters every pixel a little like bumpy glass", "radius=4", "seed=1"),
+	describe(NewTransform("streamlines", func(img image.Image, width, height int) draw.Image {
+		return streamlines(img, width, height, 8)
+	}), "flow lines following the brightness gradient", "step=8"),
+	describe(NewTransform("rorschach", func(img image.Image, width, height int) draw.Image {
+		return rorschach(img, width, height, 128)
+	}), "symmetric two tone ink blot", "threshold=128"),
+	describe(NewTransform("hue-sort-tiles", func(img image.Image, width, height int) draw.Image {
+		return hueSortTiles(img, width, height, 16)
+	}), "sorts the pixels of every tile by hue", "cell=16"),
+	describe(NewTransform("quad-tree", func(img image.Image, width, height int) draw.Image {
+		return quadTree(img, width, height, 400)
+	}), "splits busy areas into smaller and smaller flat squares", "threshold=400"),
+	describe(NewTransform("star-trails", func(img image.Image, width, height int) draw.Image {
+		return starTrails(img, width, height, 30, 24)
+	}), "bright spots smeared along arcs around the center", "angle=30", "samples=24"),
+	describe(NewTransform("text-mask", func(img image.Image, width, height int) draw.Image {
+		return textMask(img, width, height, "WACKY")
+	}), "shows the image only through big letters", "text=WACKY"),
+	describe(NewTransform("gamma", func(img image.Image, width, height int) draw.Image {
+		return adjustGamma(img, width, height, 2.2)
+	}), "brightens the midtones with a gamma curve", "gamma=2.2"),
+	describe(NewTransform("channel-gamma", func(img image.Image, width, height int) draw.Image {
+		return channelGamma(img, width, height, 1.4, 1, 0.7)
+	}), "separate gamma curve for every channel", "gamma=1.4,1,0.7"),
+	describe(NewTransform("mesh-warp", func(img image.Image, width, height int) draw.Image {
+		return meshWarp(img, width, height, 5, nil, 1)
+	}), "drags the points of a grid around and warps the image along", "grid=5", "seed=1"),
+	describe(NewTransform("color-bleed", func(img image.Image, width, height int) draw.Image {
+		return colorBleed(img, width, height, 3, 0.3)
+	}), "strong colors leak into their neighbours", "radius=3", "strength=0.3"),
+	describe(NewTransform("nested-squares", func(img image.Image, width, height int) draw.Image {
+		return nestedSquares(img, width, height, 6)
+	}), "smaller and smaller rotated copies stacked in the middle", "count=6"),
+	describe(NewTransform("cmyk-halftone", func(img image.Image, width, height int) draw.Image {
+		return cmykHalftone(img, width, height, 8)
+	}), "print style dots of cyan, magenta, yellow and black", "cell=8"),
+	describe(NewTransform("mip-blur", func(img image.Image, width, height int) draw.Image {
+		return mipBlur(img, width, height, 3)
+	}), "blur from halving the image and scaling it back up", "levels=3"),
+	describe(NewTransform("cylinder-wrap", func(img image.Image, width, height int) draw.Image {
+		return cylinderWrap(img, width, height, 0.8)
+	}), "wraps the image around a can", "curvature=0.8"),
+	describe(NewTransform("row-sort", rowSort), "shuffles whole rows into order of brightness"),
+	describe(NewTransform("chroma-edges", chromaEdges), "edges colored by the direction they run in"),
+	describe(NewTransform("low-poly", func(img image.Image, width, height int) draw.Image {
+		return lowPoly(img, width, height, 300, 1)
+	}), "flat colored triangles", "points=300", "seed=1"),
+	describe(NewTransform("thirds-shift", func(img image.Image, width, height int) draw.Image {
+		return thirdsShift(img, width, height, width/8)
+	}), "slides the top, middle and bottom third apart", "shift=1/8 width"),
+	describe(NewTransform("droplet-lens", func(img image.Image, width, height int) draw.Image {
+		return dropletLens(img, width, height, 4, 0.6)
+	}), "grid of water droplets magnifying what is under them", "grid=4", "strength=0.6"),
+	describe(NewTransform("height-map", func(img image.Image, width, height int) draw.Image {
+		return heightMap(img, width, height, 32, 40)
+	}), "lines lifted by the brightness like an old plotter", "rows=32", "scale=40"),
+	describe(NewTransform("kaleidoscope-radial", func(img image.Image, width, height int) draw.Image {
+		return kaleidoscopeN(img, width, height, 8)
+	}), "kaleidoscope made of wedges around the center", "segments=8"),
+	describe(NewTransform("brightness-warp", func(img image.Image, width, height int) draw.Image {
+		return brightnessWarp(img, width, height, 20)
+	}), "shifts pixels sideways by how bright they are", "amplitude=20"),
+	describe(NewTransform("photocopy", func(img image.Image, width, height int) draw.Image {
+		return photocopy(img, width, height, 2, 0.4)
+	}), "cheap black and white copy with toner specks", "detail=2", "darkness=0.4"),
+	describe(NewTransform("dof-blur", func(img image.Image, width, height int) draw.Image {
+		return dofBlur(img, width, height, min(width, height)/4, 4)
+	}), "sharp in the middle and blurry towards the edges", "focus=1/4 size", "sigma=4"),
+	describe(NewTransform("luma-split", func(img image.Image, width, height int) draw.Image {
+		return lumaSplit(img, width, height, 6, -6)
+	}), "red fringes in the shadows and blue in the highlights", "low=6", "high=-6"),
+	describe(NewTransform("zoom", func(img image.Image, width, height int) draw.Image {
+		return zoom(img, width, height, 1.5)
+	}), "zooms in on the center", "scale=1.5"),
+	describe(NewTransform("stipple", func(img image.Image, width, height int) draw.Image {
+		return stipple(img, width, height, 1)
+	}), "black dots, denser where the image is darker", "density=1"),
+	describe(NewTransform("page-curl", func(img image.Image, width, height int) draw.Image {
+		return pageCurl(img, width, height, 0.5)
+	}), "folds the bottom right corner over like a page", "amount=0.5"),
+	describe(NewTransform("chrome", chrome), "shiny liquid metal look"),
+	describe(NewTransform("psychedelic", func(img image.Image, width, height int) draw.Image {
+		return psychedelic(img, width, height, 6, 60)
+	}), "bands of brightness turned into bands of hue", "levels=6", "hue-step=60"),
+	describe(NewTransform("text-rings", func(img image.Image, width, height int) draw.Image {
+		return textRings(img, width, height, "WACKY")
+	}), "rings of letters colored by the image under them", "text=WACKY"),
+	describe(NewTransform("screen-print", func(img image.Image, width, height int) draw.Image {
+		return screenPrint(img, width, height, color.RGBA{20, 40, 120, 255}, color.RGBA{240, 80, 140, 255}, 4)
+	}), "two misaligned ink layers like a cheap poster", "colors=navy,pink", "offset=4"),
+	describe(NewTransform("radial-rainbow", func(img image.Image, width, height int) draw.Image {
+		return radialRainbow(img, width, height, 0.4)
+	}), "rainbow rings tinting the image from the center", "strength=0.4"),
+	describe(NewTransform("best-quad-kaleido", bestQuadKaleido), "kaleidoscope of the busiest quarter"),
+	describe(NewTransform("rank-filter", func(img image.Image, width, height int) draw.Image {
+		return rankFilter(img, width, height, 2, 0.5)
+	}), "median of the neighbourhood of every pixel", "radius=2", "rank=0.5"),
+	describe(NewTransform("diagonal-stripes", func(img image.Image, width, height int) draw.Image {
+		return diagonalStripes(img, width, height, 24)
+	}), "every other diagonal stripe mirrored", "width=24"),
+	describe(NewTransform("comic-panel", func(img image.Image, width, height int) draw.Image {
+		return comicPanel(img, width, height, "WOW!")
+	}), "halftone comic with a speech bubble", "text=WOW!"),
+	describe(NewTransform("glass-blocks", func(img image.Image, width, height int) draw.Image {
+		return glassBlocks(img, width, height, 16, 0.5)
+	}), "looking through a wall of glass bricks", "block=16", "refract=0.5"),
+	describe(NewTransform("double-vision", func(img image.Image, width, height int) draw.Image {
+		return doubleVision(img, width, height, 8, 4, 0.5)
+	}), "a faded ghost of the image slightly off to the side", "offset=8,4", "alpha=0.5"),
+	describe(NewTransform("ascii-color", func(img image.Image, width, height int) draw.Image {
+		return asciiColor(img, width, height, 8, " .:-=+*#%@")
+	}), "colored ascii art", "cell=8", "ramp= .:-=+*#%@"),
+	describe(NewTransform("prism", func(img image.Image, width, height int) draw.Image {
+		return prism(img, width, height, 8)
+	}), "splits red, green and blue diagonally apart", "spread=8"),
+	describe(NewTransform("mandala", func(img image.Image, width, height int) draw.Image {
+		return mandala(img, width, height, 6)
+	}), "round mandala of mirrored petals", "petals=6"),
+	describe(NewTransform("vector-wire", func(img image.Image, width, height int) draw.Image {
+		return vectorWire(img, width, height, 6)
+	}), "glowing contour lines on black", "levels=6"),
+	describe(NewTransform("order8", order8), "ordered dithering down to eight colors"),
+	describe(NewTransform("diagonal-fold", diagonalFold), "mirrors one triangle over the diagonal"),
+	describe(NewTransform("stained-glass", func(img image.Image, width, height int) draw.Image {
+		return stainedGlass(img, width, height, 120, 1)
+	}), "flat colored cells held together by dark lead", "cells=120", "seed=1"),
+	describe(NewTransform("threshold", func(img image.Image, width, height int) draw.Image {
+		return threshold(img, width, height, 128)
+	}), "pure black and white", "cutoff=128"),
+	describe(NewTransform("radial-trail", func(img image.Image, width, height int) draw.Image {
+		return radialTrail(img, width, height, 16)
+	}), "rays streaking out of the brightest spot", "samples=16"),
+	describe(NewTransform("equalizer-bars", func(img image.Image, width, height int) draw.Image {
+		return equalizerBars(img, width, height, 24)
+	}), "music equalizer bars as tall as the columns are bright", "bars=24"),
+	describe(NewTransform("spotlight-rect", func(img image.Image, width, height int) draw.Image {
+		return spotlightRect(img, width, height, width/3, height/3, width*2/3, height*2/3)
+	}), "keeps the colors in the middle and grays out the rest", "rect=middle third"),
+	describe(NewTransform("rank-scramble", rankScramble), "pixels laid out along a curve in order of brightness"),
+	describe(NewTransform("kmeans-posterize", func(img image.Image, width, height int) draw.Image {
+		return kmeansPosterize(img, width, height, 6, 1)
+	}), "poster made of the k most important colors", "k=6", "seed=1"),
+	describe(NewTransform("funhouse", func(img image.Image, width, height int) draw.Image {
+		return funhouse(img, width, height, 12, 2)
+	}), "wobbly mirror image like a funhouse mirror", "amplitude=12", "frequency=2"),
+	describe(NewTransform("sepia", sepia), "brownish old photo tones"),
+	describe(NewTransform("blur", func(img image.Image, width, height int) draw.Image {
+		return gaussianBlur(img, width, height, 6)
+	}), "soft focus gaussian blur", "radius=6"),
+	describe(NewTransform("edges", edgeDetect), "bright outlines where the brightness changes sharply"),
+	describe(NewTransform("pixelate", func(img image.Image, width, height int) draw.Image {
+		return pixelate(img, width, height, 8)
+	}), "blocky mosaic of average colors", "block=8"),
+	describe(NewTransform("rotate", func(img image.Image, width, height int) draw.Image {
+		return rotate(img, width, height, 15, color.RGBA{0, 0, 0, 255})
+	}), "tilts the image around its center", "degrees=15", "background=black"),
+	describe(NewTransform("flip-horizontal", flipHorizontal), "mirrors the image left to right"),
+	describe(NewTransform("flip-vertical", flipVertical), "mirrors the image top to bottom"),
+}
+
+// Built in animations, picked by name like the extras. They play out over all the
+// frames of the GIF, so they want a few of them with Options.Frames
+var animations = []Transform{
+	describe(NewAnimation("rainbow-sweep", func(img image.Image, width, height, frame, frames int) draw.Image {
+		return rainbowSweep(img, width, height, frame, frames, 0.4)
+	}), "diagonal rainbow sweeping across the image", "strength=0.4"),
+	describe(NewAnimation("jelly", func(img image.Image, width, height, frame, frames int) draw.Image {
+		return jelly(img, width, height, frame, frames, 6)
+	}), "wobbles like jelly", "amplitude=6"),
+	describe(NewAnimation("scan-beam", func(img image.Image, width, height, frame, frames int) draw.Image {
+		return scanBeam(img, width, height, frame, frames, max(height/10, 1), 120)
+	}), "bright band scanning from the top to the bottom", "width=1/10 height", "brightness=120"),
+	describe(NewAnimation("explode", func(img image.Image, width, height, frame, frames int) draw.Image {
+		return explode(img, width, height, frame, frames, color.RGBA{0, 0, 0, 255})
+	}), "pixels flying away from the center", "background=black"),
+	describe(NewAnimation("pixel-rain", func(img image.Image, width, height, frame, frames int) draw.Image {
+		return pixelRain(img, width, height, frame, frames, color.RGBA{0, 0, 0, 255})
+	}), "columns of pixels falling off the bottom", "background=black"),
+	describe(NewAnimation("kaleido-rotate", func(img image.Image, width, height, frame, frames int) draw.Image {
+		return kaleidoRotate(img, width, height, frame, frames, 8)
+	}), "radial kaleidoscope turning one full circle", "segments=8"),
+	describe(NewAnimation("type-reveal", func(img image.Image, width, height, frame, frames int) draw.Image {
+		return typeReveal(img, width, height, frame, frames, "WACKY GIF")
+	}), "caption typed out one character at a time", "caption=WACKY GIF"),
+	describe(NewAnimation("mirror-zoom", mirrorZoom), "kaleidoscope zooming in to twice the size"),
+	describe(NewAnimation("vhs-tracking", func(img image.Image, width, height, frame, frames int) draw.Image {
+		return vhsTracking(img, width, height, frame, frames, 0.6)
+	}), "worn VHS tape with jumping tracking noise", "intensity=0.6"),
+	describe(NewAnimation("v-roll", vRoll), "picture rolling up like a TV losing vertical hold"),
+	describe(NewAnimation("breathe", breathe), "slowly zooming in and out"),
+	describe(NewAnimation("laser-grid", func(img image.Image, width, height, frame, frames int) draw.Image {
+		return laserGrid(img, width, height, frame, frames, 24, 0.6)
+	}), "green laser lines sweeping across and then down", "spacing=24", "blend=0.6"),
+	describe(NewAnimation("gravity", gravity), "bright pixels sinking to the bottom like sand"),
+	describe(NewAnimation("signal-jam", signalJam), "reception tearing up and recovering"),
+	describe(NewAnimation("threshold-pulse", thresholdPulse), "black and white with a pulsing cutoff"),
+	describe(NewAnimation("rain-streaks", func(img image.Image, width, height, frame, frames int) draw.Image {
+		return rainStreaks(img, width, height, frame, frames, 0.3, 15)
+	}), "slanted rain falling over a soft background", "density=0.3", "angle=15"),
+}
+
+func init() {
+	for _, list := range [][]Transform{builtins, extras, animations} {
+		for _, t := range list {
+			Register(t.Name(), t)
+		}
+	}
+}
+
+// List of transformations making up the frames when none are picked, in declaration order
+func defaultTransformations() []Transform {
+	return append([]Transform(nil), builtins...)
+}
+
+// Returns the image moved so its bounds start at (0, 0), for example when it
+// is a SubImage of something bigger. Images already at the origin are returned as is
+func translateToOrigin(img image.Image) image.Image {
+	bounds := img.Bounds()
+	if bounds.Min == (image.Point{}) {
+		return img
+	}
+	newImg := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(newImg, newImg.Bounds(), img, bounds.Min, draw.Src)
+	return newImg
+}
+
+// Blends every frame with decay times the frame emitted before it. That frame already
+// carries the echo of the ones before, so the trail fades out over the following frames
+func echoFrames(frames []draw.Image, decay float64) {
+	for i := 1; i < len(frames); i++ {
+		prev, cur := frames[i-1], frames[i]
+		bounds := cur.Bounds().Intersect(prev.Bounds())
+		blended := image.NewRGBA(cur.Bounds())
+		draw.Draw(blended, blended.Bounds(), cur, cur.Bounds().Min, draw.Src)
+
+		for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+			for x := bounds.Min.X; x < bounds.Max.X; x++ {
+				r1, g1, b1, a1 := cur.At(x, y).RGBA()
+				r2, g2, b2, a2 := prev.At(x, y).RGBA()
+				mix := func(c1, c2 uint32) uint8 {
+					return uint8(math.Round(float64(c1>>8)*(1-decay) + float64(c2>>8)*decay))
+				}
+				blended.SetRGBA(x, y, color.RGBA{mix(r1, r2), mix(g1, g2), mix(b1, b2), mix(a1, a2)})
+			}
+		}
+		frames[i] = blended
+	}
+}
+
+func convertToPaletted(img image.Image, pal color.Palette) *image.Paletted {
+	bounds := img.Bounds()
+	return ditherToPalette(img, bounds.Dx(), bounds.Dy(), pal).(*image.Paletted)
+}
+
+func shuffle(slice []Transform, rng *rand.Rand) {
+	for i := len(slice) - 1; i > 0; i-- {
+		j := rng.Intn(i + 1)
+		slice[i], slice[j] = slice[j], slice[i]
+	}
+}
+
+// Collects the unique colors of an image in reading order. A GIF palette can
+// hold at most 256 colors so anything after that is ignored
+func PaletteFromImage(paletteImg image.Image) color.Palette {
+	var pal color.Palette
+	seen := make(map[color.RGBA]bool)
+	bounds := paletteImg.Bounds()
+
+	for y := bounds.Min.Y; y < bounds.Max.Y && len(pal) < 256; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X && len(pal) < 256; x++ {
+			col := color.RGBAModel.Convert(paletteImg.At(x, y)).(color.RGBA)
+			if !seen[col] {
+				seen[col] = true
+				pal = append(pal, col)
+			}
+		}
+	}
+	return pal
+}
@@ -0,0 +1,198 @@
+package wackygif
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"sync/atomic"
+	"testing"
+)
+
+func TestPaletteFromSwatch(t *testing.T) {
+	swatch := []color.RGBA{
+		{0, 0, 0, 255},
+		{255, 255, 255, 255},
+		{200, 30, 30, 255},
+		{30, 60, 200, 255},
+	}
+	// Every color shows up more than once, they should still only be listed once
+	swatchImg := newTestImage(8, 4, func(x, y int) color.RGBA { return swatch[(x/2+y)%len(swatch)] })
+
+	pal := PaletteFromImage(swatchImg)
+	if len(pal) != len(swatch) {
+		t.Fatalf("palette has %d colors, want %d", len(pal), len(swatch))
+	}
+	inSwatch := make(map[color.RGBA]bool)
+	for _, c := range swatch {
+		inSwatch[c] = true
+	}
+	for _, c := range pal {
+		if !inSwatch[c.(color.RGBA)] {
+			t.Errorf("palette color %v is not in the swatch", c)
+		}
+	}
+
+	const size = 16
+	src := noiseImage(size, size, 34)
+	dithered := ditherToPalette(src, size, size, pal)
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			if c := rgbaAt(dithered, x, y); !inSwatch[c] {
+				t.Fatalf("pixel (%d, %d) is %v, which is not in the swatch", x, y, c)
+			}
+		}
+	}
+	// The GIF frames are dithered the same way
+	assertSameImage(t, convertToPaletted(src, pal), dithered)
+}
+
+// Encodes g and reads it back, the way a GIF viewer would see it
+func roundTrip(t *testing.T, g *gif.GIF) *gif.GIF {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := gif.EncodeAll(&buf, g); err != nil {
+		t.Fatalf("encoding: %v", err)
+	}
+	decoded, err := gif.DecodeAll(&buf)
+	if err != nil {
+		t.Fatalf("decoding: %v", err)
+	}
+	return decoded
+}
+
+func TestGenerateGIFFrameCount(t *testing.T) {
+	src := noiseImage(12, 10, 35)
+	picked := []string{"swap", "wave", "bright"}
+
+	tests := []struct {
+		name   string
+		frames int
+		want   int
+	}{
+		{"one per transform", 0, 3},
+		{"truncated", 2, 2},
+		{"single", 1, 1},
+		{"cycled", 7, 7},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, err := GenerateGIF(src, Options{Transforms: picked, Frames: tt.frames})
+			if err != nil {
+				t.Fatal(err)
+			}
+			decoded := roundTrip(t, g)
+			if len(decoded.Image) != tt.want || len(decoded.Delay) != tt.want {
+				t.Fatalf("%d frames and %d delays, want %d of each", len(decoded.Image), len(decoded.Delay), tt.want)
+			}
+			// Cycled frames come back to the same transform
+			for i := len(picked); i < tt.want; i++ {
+				assertSameImage(t, decoded.Image[i], decoded.Image[i-len(picked)])
+			}
+		})
+	}
+}
+
+// Cycling through a static transform reuses the frame instead of rendering it again
+func TestGenerateGIFRendersStaticOnce(t *testing.T) {
+	const name = "test-counted"
+	var calls atomic.Int32
+	Register(name, NewTransform(name, func(img image.Image, width, height int) draw.Image {
+		calls.Add(1)
+		return identity(img, width, height)
+	}))
+	defer func() {
+		registryMu.Lock()
+		delete(registry, name)
+		registryMu.Unlock()
+	}()
+
+	g, err := GenerateGIF(noiseImage(8, 8, 36), Options{Transforms: []string{name, "swap"}, Frames: 9})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(g.Image) != 9 {
+		t.Fatalf("%d frames, want 9", len(g.Image))
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("rendered %d times, want once", n)
+	}
+}
+
+// A SubImage keeps the coordinates of its parent, GenerateGIF has to treat it like
+// the same pixels copied to an image of their own
+func TestGenerateGIFSubImage(t *testing.T) {
+	full := noiseImage(20, 16, 44)
+	rect := image.Rect(5, 3, 17, 12)
+	sub := full.SubImage(rect)
+	cropped := newTestImage(rect.Dx(), rect.Dy(), func(x, y int) color.RGBA { return rgbaAt(full, rect.Min.X+x, rect.Min.Y+y) })
+
+	assertSameImage(t, translateToOrigin(sub), cropped)
+
+	opts := Options{Transforms: []string{"swap", "vertical", "wave", "sick-twist"}}
+	got, err := GenerateGIF(sub, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := GenerateGIF(cropped, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Image) != len(want.Image) {
+		t.Fatalf("%d frames, want %d", len(got.Image), len(want.Image))
+	}
+	for i := range want.Image {
+		assertSameImage(t, got.Image[i], want.Image[i])
+	}
+}
+
+func TestGenerateGIFLoopCount(t *testing.T) {
+	src := noiseImage(8, 6, 45)
+	for _, loop := range []int{0, -1, 3} {
+		g, err := GenerateGIF(src, Options{Transforms: []string{"swap", "wave"}, LoopCount: loop})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := roundTrip(t, g).LoopCount; got != loop {
+			t.Errorf("encoded loop count %d, want %d", got, loop)
+		}
+	}
+}
+
+func TestGenerateGIFEcho(t *testing.T) {
+	dark, light := color.RGBA{0, 0, 0, 255}, color.RGBA{250, 250, 250, 255}
+	for name, col := range map[string]color.RGBA{"test-dark": dark, "test-light": light} {
+		Register(name, NewTransform(name, func(img image.Image, width, height int) draw.Image {
+			return uniformImage(width, height, col)
+		}))
+		defer func() {
+			registryMu.Lock()
+			delete(registry, name)
+			registryMu.Unlock()
+		}()
+	}
+	// Every gray level is in the palette so the frames come out without dithering
+	var grays color.Palette
+	for i := 0; i < 256; i++ {
+		grays = append(grays, color.RGBA{uint8(i), uint8(i), uint8(i), 255})
+	}
+
+	src := noiseImage(6, 6, 46)
+	render := func(echo float64) color.RGBA {
+		g, err := GenerateGIF(src, Options{Transforms: []string{"test-dark", "test-light"}, Echo: echo, Palette: grays})
+		if err != nil {
+			t.Fatal(err)
+		}
+		assertSameImage(t, g.Image[0], uniformImage(6, 6, dark))
+		return rgbaAt(g.Image[1], 3, 3)
+	}
+
+	if got := render(0); got != light {
+		t.Errorf("without echo the second frame is %v, want %v", got, light)
+	}
+	// 5% of the light frame on top of 95% of the dark one
+	if got := render(0.95); colorDiff(got, color.RGBA{13, 13, 13, 255}) > 1 {
+		t.Errorf("with echo 0.95 the second frame is %v, want it close to the dark frame before it", got)
+	}
+}